@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/wallarm/gotestwaf/internal/config"
+	"github.com/wallarm/gotestwaf/internal/report"
+	"github.com/wallarm/gotestwaf/internal/scanner"
+	"github.com/wallarm/gotestwaf/internal/version"
+)
+
+// dryRun builds the requests of the selected tests without sending them and
+// prints their number, size and the estimated duration of the scan. The
+// requests are written to the --dryRunDump file if it is set.
+func dryRun(ctx context.Context, cfg *config.Config, logger *logrus.Logger) (err error) {
+	logger.WithField("version", version.Version).Info("GoTestWAF started in dry run mode")
+
+	testsDB, templates, _, err := loadTests(ctx, cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	var dump io.Writer
+
+	if cfg.DryRunDump != "" {
+		f, createErr := os.Create(cfg.DryRunDump)
+		if createErr != nil {
+			return errors.Wrap(createErr, "couldn't create dry run dump file")
+		}
+		defer func() {
+			if closeErr := f.Close(); closeErr != nil && err == nil {
+				err = errors.Wrap(closeErr, "couldn't write dry run dump file")
+			}
+		}()
+
+		dump = f
+	}
+
+	stats, err := scanner.DryRun(ctx, cfg, testsDB, templates, dump)
+	if err != nil {
+		return errors.Wrap(err, "couldn't build requests")
+	}
+
+	if err = report.RenderDryRunReport(stats, logFormat); err != nil {
+		return err
+	}
+
+	if cfg.DryRunDump != "" {
+		logger.WithField("filename", cfg.DryRunDump).Info("Export dry run requests")
+	}
+
+	return nil
+}
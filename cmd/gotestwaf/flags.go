@@ -17,8 +17,11 @@ import (
 	"golang.org/x/crypto/ssh/terminal"
 
 	"github.com/wallarm/gotestwaf/internal/config"
+	"github.com/wallarm/gotestwaf/internal/db"
 	"github.com/wallarm/gotestwaf/internal/helpers"
+	"github.com/wallarm/gotestwaf/internal/payload/template"
 	"github.com/wallarm/gotestwaf/internal/report"
+	"github.com/wallarm/gotestwaf/internal/scanner"
 	"github.com/wallarm/gotestwaf/internal/version"
 )
 
@@ -48,6 +51,14 @@ var (
 	httpClients = slices.Collect(maps.Keys(httpClientsSet))
 )
 
+var (
+	tarpitActionsSet = map[string]any{
+		scanner.TarpitAsBlocked:   nil,
+		scanner.TarpitAsTarpitted: nil,
+	}
+	tarpitActions = slices.Collect(maps.Keys(tarpitActionsSet))
+)
+
 const (
 	maxReportFilenameLength = 249 // 255 (max length) - 5 (".html") - 1 (to be sure)
 
@@ -63,7 +74,11 @@ const cliDescription = `GoTestWAF is a tool for API and OWASP attack simulation
 wide range of API protocols including REST, GraphQL, gRPC, SOAP, XMLRPC, and others.
 Homepage: https://github.com/wallarm/gotestwaf
 
-Usage: %s [OPTIONS] --url <URL>
+Usage: %[1]s [OPTIONS] --url <URL>
+       %[1]s replay [OPTIONS] --url <URL> <debug hash | set/case/payload index>
+       %[1]s lint [--testCasesPath <PATH>]
+       %[1]s import crs [--testCasesPath <PATH>] <test set> <CRS tests path>...
+       %[1]s import raw [--testCasesPath <PATH>] [--include <SELECTOR>] <test set> <request file>...
 
 Options:
 `
@@ -74,6 +89,17 @@ var (
 	logLevel   logrus.Level
 	logFormat  string
 
+	// command is the subcommand, it is empty for the scan
+	command string
+	// replayTest is the test selected for the replay command
+	replayTest string
+
+	// importFormat, importTestSet and importPaths are the arguments of the
+	// import command
+	importFormat  string
+	importTestSet string
+	importPaths   []string
+
 	isIncludePayloadsFlagUsed bool
 )
 
@@ -89,6 +115,11 @@ func parseFlags() (args []string, err error) {
 	reportPath := filepath.Join(".", defaultReportPath)
 	testCasesPath := filepath.Join(".", defaultTestCasesPath)
 
+	// The embedded test cases are used if there is no test cases directory
+	if _, err = os.Stat(testCasesPath); err != nil {
+		testCasesPath = ""
+	}
+
 	flag.Usage = usage
 
 	// General parameters
@@ -106,15 +137,29 @@ func parseFlags() (args []string, err error) {
 
 	// Test cases settings
 	flag.String("testCase", "", "If set then only this test case will be run")
-	flag.String("testCasesPath", testCasesPath, "Path to a folder with test cases")
+	flag.String("testCasesPath", testCasesPath, "Path to a folder with test cases, the embedded test cases are used if empty")
+	flag.StringSlice("addTestCasesPath", nil, "Path to a folder with test cases added on top of the test cases path, the test case files with the same paths are replaced")
 	flag.String("testSet", "", "If set then only this test set's cases will be run")
+	include := flag.StringSlice("include", nil, "Run only the tests matching the selectors: [set|case|tag|type|encoder|placeholder=]<glob>, e.g. owasp/*, tag=sqli, placeholder=Header")
+	exclude := flag.StringSlice("exclude", nil, "Skip the tests matching the selectors, in the same format as for --include")
+	templateVars := flag.StringSlice("templateVar", nil, "A custom variable for the payload templates in the format <name>=<value>")
+	flag.Int64("templateSeed", 0, "Seed of the random generators in the payload templates, a random seed is used if not set")
 
 	// HTTP client settings
 	httpClient := flag.String("httpClient", gohttpClient, "Which HTTP client use to send requests: "+strings.Join(httpClients, ", "))
 	flag.Bool("tlsVerify", false, "If present, the received TLS certificate will be verified")
+	flag.String("tlsClientCert", "", "Path to a PEM encoded client certificate for mutual TLS (not supported by chrome)")
+	flag.String("tlsClientKey", "", "Path to a PEM encoded private key for the client certificate (not supported by chrome)")
+	flag.String("tlsCA", "", "Path to a PEM encoded CA bundle used to verify the server certificate (implies --tlsVerify)")
+	flag.String("tlsServerName", "", "Server name to send in SNI and to verify the certificate against (not supported by chrome)")
+	flag.String("tlsMinVersion", "", "Minimum TLS version: 1.0, 1.1, 1.2, 1.3")
+	flag.String("tlsMaxVersion", "", "Maximum TLS version: 1.0, 1.1, 1.2, 1.3")
 	flag.String("proxy", "", "Proxy URL to use")
 	flag.String("addHeader", "", "An HTTP header to add to requests")
-	flag.Bool("addDebugHeader", false, "Add header \"X-GoTestWAF-Test\" with a hash of the test information in each request")
+	flag.Bool("addDebugHeader", false, "Add header \"X-GoTestWAF-Test\" with a hash of the test information in each request and save the index of the hashes next to the reports")
+	maxResponseSize := flag.Int("maxResponseSize", 0, "The maximum number of response body bytes to read, 0 means no limit (gohttp and GraphQL requests, WAF identification; not supported by chrome)")
+	responseReadTimeout := flag.Int("responseReadTimeout", 0, "The maximum amount of time in seconds to read a response body, 0 means no limit (gohttp and GraphQL requests, WAF identification; not supported by chrome)")
+	flag.Bool("streamFirstEventOnly", false, "If present, read only the first event of text/event-stream and the first line of JSON lines responses (gohttp and GraphQL requests, WAF identification; not supported by chrome)")
 
 	// GoHTTP client only settings
 	flag.Int("maxIdleConns", 2, "The maximum number of keep-alive connections (gohttp only)")
@@ -122,15 +167,21 @@ func parseFlags() (args []string, err error) {
 	flag.Int("idleConnTimeout", 2, "The maximum amount of time a keep-alive connection will live (gohttp only)")
 	flag.Bool("followCookies", false, "If present, use cookies sent by the server. May work only with --maxIdleConns=1 (gohttp only)")
 	flag.Bool("renewSession", false, "Renew cookies before each test. Should be used with --followCookies flag (gohttp only)")
+	flag.Bool("solveJSChallenge", false, "If present, solve a JavaScript challenge in headless Chrome and pass its cookies and User-Agent to requests (gohttp only)")
+	jsChallengeTimeout := flag.Int("jsChallengeTimeout", 30, "The maximum amount of time in seconds to solve a JavaScript challenge (gohttp only)")
 
 	// Performance settings
 	flag.Int("workers", 5, "The number of workers to scan")
 	flag.Int("sendDelay", 400, "Delay in ms between requests")
 	flag.Int("randomDelay", 400, "Random delay in ms in addition to the delay between requests")
+	dryRun := flag.Bool("dryRun", false, "If present, build the requests of the selected tests without sending them and print their number, size and the estimated scan duration")
+	flag.String("dryRunDump", "", "A file to write the requests built with --dryRun to")
 
 	// Analysis settings
 	flag.Bool("skipWAFBlockCheck", false, "If present, WAF detection tests will be skipped")
 	flag.Bool("skipWAFIdentification", false, "Skip WAF identification")
+	flag.String("wafDetectorsPath", "", "Path to a directory with additional WAF detector definitions in YAML format")
+	flag.String("wafDetector", "", "Name of the identified WAF whose signature is used to detect blocked requests, the first identified WAF by default")
 	flag.IntSlice("blockStatusCodes", []int{403}, "HTTP status code that WAF uses while blocking requests")
 	flag.IntSlice("passStatusCodes", []int{200, 404}, "HTTP response status code that WAF uses while passing requests")
 	blockRegex := flag.String("blockRegex", "",
@@ -141,6 +192,10 @@ func parseFlags() (args []string, err error) {
 		"If present, count requests that weren't blocked as passed. If false, requests that don't satisfy to PassStatusCodes/PassRegExp as blocked")
 	flag.Bool("ignoreUnresolved", false, "If present, unresolved test cases will be considered as bypassed (affect score and results)")
 	flag.Bool("blockConnReset", false, "If present, connection resets will be considered as block")
+	flag.Bool("baseline", false, "If present, send a benign request through each placeholder and OpenAPI request template before scanning, and compare test responses with it")
+	flag.Bool("calibrate", false, "If present, learn the block page from benign and malicious requests before scanning and classify responses by similarity to it")
+	tarpitDetection := flag.String("tarpitDetection", "", "Classify significantly delayed or timed out requests compared to benign ones: "+strings.Join(tarpitActions, ", "))
+	tarpitTimeout := flag.Int("tarpitTimeout", 30, "Timeout in seconds after which a test request is considered tarpitted, used with --tarpitDetection")
 
 	// Report settings
 	flag.String("wafName", wafName, "Name of the WAF product")
@@ -151,6 +206,8 @@ func parseFlags() (args []string, err error) {
 	noEmailReport := flag.Bool("noEmailReport", false, "Save report locally")
 	email := flag.String("email", "", "E-mail to which the report will be sent")
 	flag.Bool("hideArgsInReport", false, "If present, GoTestWAF CLI arguments will not be displayed in the report")
+	flag.Bool("includeEvidence", false, "If present, response headers, a body excerpt and a body hash will be saved for each test and included in JSON/HTML reports")
+	evidenceBodySize := flag.Int("evidenceBodySize", 1024, "Maximum size in bytes of the response body excerpt saved with --includeEvidence")
 
 	flag.Parse()
 
@@ -165,12 +222,17 @@ func parseFlags() (args []string, err error) {
 		os.Exit(0)
 	}
 
+	if err = parseCommand(); err != nil {
+		return nil, err
+	}
+
 	// url flag must be set
-	if *urlParam == "" {
+	if *urlParam == "" && command != lintCommand && command != importCommand {
 		return nil, errors.New("--url flag is not set")
 	}
 
-	if !terminal.IsTerminal(int(os.Stdin.Fd())) {
+	// The report isn't sent in the dry run
+	if command == "" && !*dryRun && !terminal.IsTerminal(int(os.Stdin.Fd())) {
 		if *noEmailReport == false && *email == "" {
 			return nil, errors.New(
 				"GoTestWAF is running in a non-interactive session. " +
@@ -197,10 +259,31 @@ func parseFlags() (args []string, err error) {
 		return nil, err
 	}
 
+	// The lint and import commands use only the test cases path
+	if command == lintCommand || command == importCommand {
+		return nil, nil
+	}
+
 	if err = validateHttpClient(*httpClient); err != nil {
 		return nil, err
 	}
 
+	if err = validateTarpitDetection(*tarpitDetection); err != nil {
+		return nil, err
+	}
+
+	if _, err = db.NewSelectors(*include, *exclude); err != nil {
+		return nil, errors.Wrap(err, "couldn't parse --include or --exclude")
+	}
+
+	if *evidenceBodySize < 0 {
+		return nil, errors.New("--evidenceBodySize must not be negative")
+	}
+
+	if *tarpitTimeout <= 0 {
+		return nil, errors.New("--tarpitTimeout must be positive")
+	}
+
 	if err = report.ValidateReportFormat(*reportFormat); err != nil {
 		return nil, err
 	}
@@ -211,6 +294,10 @@ func parseFlags() (args []string, err error) {
 	}
 	*urlParam = validURL.String()
 
+	if _, err = template.Vars(*urlParam, *templateVars); err != nil {
+		return nil, errors.Wrap(err, "couldn't parse --templateVar")
+	}
+
 	// format GraphQL URL from given HTTP URL
 	gqlValidURL, err := checkOrCraftProtocolURL(*graphqlURL, *urlParam, graphqlProto)
 	if err != nil {
@@ -238,6 +325,18 @@ func parseFlags() (args []string, err error) {
 		}
 	}
 
+	if *maxResponseSize < 0 {
+		return nil, errors.New("--maxResponseSize must not be negative")
+	}
+
+	if *responseReadTimeout < 0 {
+		return nil, errors.New("--responseReadTimeout must not be negative")
+	}
+
+	if *jsChallengeTimeout <= 0 {
+		return nil, errors.New("--jsChallengeTimeout must be positive")
+	}
+
 	_, reportFileName := filepath.Split(*reportName)
 	if len(reportFileName) > maxReportFilenameLength {
 		return nil, errors.New("report filename too long")
@@ -253,6 +352,51 @@ func parseFlags() (args []string, err error) {
 	return args, nil
 }
 
+// parseCommand parses the subcommand and its arguments.
+func parseCommand() error {
+	command = flag.Arg(0)
+
+	switch command {
+	case "":
+		return nil
+
+	case replayCommand:
+		if flag.NArg() != 2 {
+			return errors.New("replay command requires a debug hash or set/case/payload index")
+		}
+
+		replayTest = flag.Arg(1)
+
+		return nil
+
+	case lintCommand:
+		if flag.NArg() != 1 {
+			return errors.New("lint command doesn't accept arguments")
+		}
+
+		return nil
+
+	case importCommand:
+		if flag.NArg() < 4 {
+			return errors.New("import command requires a format, a test set and paths to import")
+		}
+
+		importFormat = flag.Arg(1)
+		if !slices.Contains(importFormats, importFormat) {
+			return errors.Errorf("unknown import format: %s, expected one of: %s",
+				importFormat, strings.Join(importFormats, ", "))
+		}
+
+		importTestSet = flag.Arg(2)
+		importPaths = flag.Args()[3:]
+
+		return nil
+
+	default:
+		return errors.Errorf("unknown command: %s", command)
+	}
+}
+
 func checkUsedFlags() {
 	fn := func(f *flag.Flag) {
 		if f.Name == "includePayloads" {
@@ -304,7 +448,7 @@ func normalizeArgs() ([]string, error) {
 		case "bool":
 			arg = fmt.Sprintf("--%s", f.Name)
 
-		case "int", "uint16":
+		case "int", "int64", "uint16":
 			value = f.Value.String()
 			arg = fmt.Sprintf("--%s=%s", f.Name, value)
 
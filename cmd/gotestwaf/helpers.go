@@ -5,6 +5,10 @@ import (
 	"fmt"
 	"net/url"
 	"regexp"
+	"strings"
+
+	"github.com/wallarm/gotestwaf/internal/scanner/waf_detector"
+	"github.com/wallarm/gotestwaf/internal/scanner/waf_detector/detectors"
 )
 
 const (
@@ -74,6 +78,18 @@ func validateHttpClient(httpClient string) error {
 	return nil
 }
 
+func validateTarpitDetection(action string) error {
+	if action == "" {
+		return nil
+	}
+
+	if _, ok := tarpitActionsSet[action]; !ok {
+		return fmt.Errorf("invalid tarpit detection action: %s", action)
+	}
+
+	return nil
+}
+
 func validateLogFormat(logFormat string) error {
 	if _, ok := logFormatsSet[logFormat]; !ok {
 		return fmt.Errorf("invalid log format: %s", logFormat)
@@ -81,3 +97,23 @@ func validateLogFormat(logFormat string) error {
 
 	return nil
 }
+
+// pickWAFDetector returns the identified WAF detector with the given name. If
+// the name is empty, the first identified detector is returned.
+func pickWAFDetector(matches []*waf_detector.Match, name string) (*detectors.Detector, error) {
+	if name == "" {
+		return matches[0].Detector, nil
+	}
+
+	var names []string
+	for _, match := range matches {
+		if strings.EqualFold(match.Detector.WAFName, name) {
+			return match.Detector, nil
+		}
+
+		names = append(names, match.Detector.WAFName)
+	}
+
+	return nil, fmt.Errorf("WAF %q set by --wafDetector wasn't identified, identified WAFs: %s",
+		name, strings.Join(names, ", "))
+}
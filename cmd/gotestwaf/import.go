@@ -0,0 +1,82 @@
+package main
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/wallarm/gotestwaf/internal/config"
+	"github.com/wallarm/gotestwaf/internal/db"
+	"github.com/wallarm/gotestwaf/internal/importer"
+)
+
+const (
+	importCommand = "import"
+
+	crsImportFormat = "crs"
+	rawImportFormat = "raw"
+)
+
+var importFormats = []string{crsImportFormat, rawImportFormat}
+
+// importTests converts the tests in the format to the test cases and writes
+// them to the new test set in the test cases path.
+func importTests(cfg *config.Config, logger *logrus.Logger, format, testSet string, paths []string) error {
+	var (
+		testCases []*importer.TestCase
+		skipped   int
+		err       error
+	)
+
+	// The embedded test cases can't be changed
+	if cfg.TestCasesPath == "" {
+		return errors.New("--testCasesPath flag is not set")
+	}
+
+	switch format {
+	case crsImportFormat:
+		testCases, skipped, err = importer.ImportCRS(paths)
+
+	case rawImportFormat:
+		testCases, err = importRaw(cfg, paths)
+
+	default:
+		return errors.Errorf("unknown import format: %s", format)
+	}
+	if err != nil {
+		return errors.Wrap(err, "couldn't import tests")
+	}
+
+	if err = importer.Write(cfg.TestCasesPath, testSet, testCases); err != nil {
+		return errors.Wrap(err, "couldn't write test cases")
+	}
+
+	var payloads int
+	for _, tc := range testCases {
+		payloads += len(tc.Payloads)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"test_set":   testSet,
+		"test_cases": len(testCases),
+		"payloads":   payloads,
+		"skipped":    skipped,
+	}).Info("Tests imported")
+
+	return nil
+}
+
+// importRaw combines the requests with the payloads of the test cases
+// selected in the test cases path and the additional test cases paths.
+func importRaw(cfg *config.Config, paths []string) ([]*importer.TestCase, error) {
+	requests, err := importer.ImportRaw(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	corpus, err := db.LoadTestCases(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't load test cases")
+	}
+
+	return importer.WithRequests(corpus, requests), nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/wallarm/gotestwaf/internal/config"
+	"github.com/wallarm/gotestwaf/internal/db"
+)
+
+const lintCommand = "lint"
+
+// lint checks the test case files, including the files in the additional
+// test cases paths, and prints the found problems.
+func lint(cfg *config.Config, logger *logrus.Logger) error {
+	fsys, err := db.NewTestCasesFS(cfg.TestCasesPath, cfg.AddTestCasesPaths)
+	if err != nil {
+		return err
+	}
+
+	diagnostics, err := db.LintTestCases(fsys)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range diagnostics {
+		fmt.Println(d)
+	}
+
+	if len(diagnostics) > 0 {
+		return errors.Errorf("found %d problems in test cases", len(diagnostics))
+	}
+
+	testCasesPath := cfg.TestCasesPath
+	if testCasesPath == "" {
+		testCasesPath = db.EmbeddedTestCasesPath
+	}
+
+	logger.WithField("path", testCasesPath).Info("No problems found in test cases")
+
+	return nil
+}
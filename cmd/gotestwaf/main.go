@@ -19,6 +19,8 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/wallarm/gotestwaf/internal/db"
+	"github.com/wallarm/gotestwaf/internal/debugindex"
+	"github.com/wallarm/gotestwaf/internal/har"
 	"github.com/wallarm/gotestwaf/internal/helpers"
 	"github.com/wallarm/gotestwaf/internal/openapi"
 	"github.com/wallarm/gotestwaf/internal/report"
@@ -66,7 +68,21 @@ func main() {
 		cfg.Args = args
 	}
 
-	if err := run(ctx, cfg, logger); err != nil {
+	switch command {
+	case replayCommand:
+		err = replay(ctx, cfg, logger, replayTest)
+	case lintCommand:
+		err = lint(cfg, logger)
+	case importCommand:
+		err = importTests(cfg, logger, importFormat, importTestSet, importPaths)
+	default:
+		if cfg.DryRun {
+			err = dryRun(ctx, cfg, logger)
+		} else {
+			err = run(ctx, cfg, logger)
+		}
+	}
+	if err != nil {
 		logger.WithError(err).Error("caught error in main function")
 		os.Exit(1)
 	}
@@ -75,114 +91,46 @@ func main() {
 func run(ctx context.Context, cfg *config.Config, logger *logrus.Logger) error {
 	logger.WithField("version", version.Version).Info("GoTestWAF started")
 
-	var err error
-	var router routers.Router
-	var templates openapi.Templates
-
-	if cfg.OpenAPIFile != "" {
-		var openapiDoc *openapi3.T
-
-		openapiDoc, router, err = openapi.LoadOpenAPISpec(ctx, cfg.OpenAPIFile)
-		if err != nil {
-			return errors.Wrap(err, "couldn't load OpenAPI spec")
-		}
-		openapiDoc.Servers = append(openapiDoc.Servers, &openapi3.Server{
-			URL: cfg.URL,
-		})
-
-		templates, err = openapi.NewTemplates(openapiDoc, cfg.URL)
-		if err != nil {
-			return errors.Wrap(err, "couldn't create templates from OpenAPI file")
-		}
-	}
-
-	logger.Info("Test cases loading started")
-
-	testCases, err := db.LoadTestCases(cfg)
+	s, db, err := newScanner(ctx, cfg, logger)
 	if err != nil {
-		return errors.Wrap(err, "loading test case")
-	}
-
-	logger.Info("Test cases loading finished")
-
-	db, err := db.NewDB(testCases)
-	if err != nil {
-		return errors.Wrap(err, "couldn't create test cases DB")
+		return err
 	}
+	defer s.Close()
 
-	logger.WithField("fp", db.Hash).Info("Test cases fingerprint")
-
-	if !cfg.SkipWAFIdentification {
-		detector, err := waf_detector.NewWAFDetector(logger, cfg)
-		if err != nil {
-			return errors.Wrap(err, "couldn't create WAF waf_detector")
-		}
-
-		logger.Info("Try to identify WAF solution")
-
-		name, vendor, checkFunc, err := detector.DetectWAF(ctx)
-		if err != nil {
-			return errors.Wrap(err, "couldn't detect")
-		}
-
-		if name != "" && vendor != "" {
-			logger.WithFields(logrus.Fields{
-				"solution": name,
-				"vendor":   vendor,
-			}).Info("WAF was identified. Force enabling `--followCookies' and `--renewSession' options")
-
-			cfg.CheckBlockFunc = checkFunc
-			cfg.FollowCookies = true
-			cfg.RenewSession = true
-			cfg.WAFName = fmt.Sprintf("%s (%s)", name, vendor)
-		} else {
-			logger.Info("WAF was not identified")
+	_, err = os.Stat(cfg.ReportPath)
+	if os.IsNotExist(err) {
+		if makeErr := os.Mkdir(cfg.ReportPath, 0700); makeErr != nil {
+			return errors.Wrap(makeErr, "creating dir")
 		}
 	}
 
-	logger.WithField("http_client", cfg.HTTPClient).
-		Infof("%s is used as an HTTP client to make requests", cfg.HTTPClient)
-
-	s, err := scanner.New(logger, cfg, db, templates, router, cfg.AddDebugHeader)
-	if err != nil {
-		return errors.Wrap(err, "couldn't create scanner")
-	}
-
-	if cfg.HTTPClient != "chrome" {
-		isJsReuqired, err := s.CheckIfJavaScriptRequired(ctx)
+	var harWriter *har.Writer
+	if report.IsHarReportFormat(cfg.ReportFormat) {
+		harWriter, err = har.NewWriter(cfg.ReportPath)
 		if err != nil {
-			return errors.Wrap(err, "couldn't check if JavaScript is required to interact with the endpoint")
+			return errors.Wrap(err, "couldn't create HAR file")
 		}
+		defer harWriter.Close()
 
-		if isJsReuqired {
-			return errors.New("JavaScript is required to interact with the endpoint")
-		}
+		s.SetHARWriter(harWriter)
 	}
 
-	if !cfg.SkipWAFBlockCheck {
-		err = s.WAFBlockCheck(ctx)
+	var indexWriter *debugindex.Writer
+	if cfg.AddDebugHeader {
+		indexWriter, err = debugindex.NewWriter(cfg.ReportPath)
 		if err != nil {
-			return err
+			return errors.Wrap(err, "couldn't create debug header index file")
 		}
-	} else {
-		logger.WithField("status", "skipped").Info("WAF pre-check")
-	}
+		defer indexWriter.Close()
 
-	s.CheckGRPCAvailability(ctx)
-	s.CheckGraphQLAvailability(ctx)
+		s.SetDebugIndexWriter(indexWriter)
+	}
 
 	err = s.Run(ctx)
 	if err != nil {
 		return errors.Wrap(err, "error occurred while scanning")
 	}
 
-	_, err = os.Stat(cfg.ReportPath)
-	if os.IsNotExist(err) {
-		if makeErr := os.Mkdir(cfg.ReportPath, 0700); makeErr != nil {
-			return errors.Wrap(makeErr, "creating dir")
-		}
-	}
-
 	reportTime := time.Now()
 
 	// Set report name as entered
@@ -195,6 +143,15 @@ func run(ctx context.Context, cfg *config.Config, logger *logrus.Logger) error {
 
 	reportFile := filepath.Join(cfg.ReportPath, reportName)
 
+	if indexWriter != nil {
+		indexFile := reportFile + ".debug.jsonl"
+		if err = indexWriter.Save(indexFile); err != nil {
+			return errors.Wrap(err, "couldn't export debug header index")
+		}
+
+		logger.WithField("filename", indexFile).Info("Export debug header index")
+	}
+
 	stat := db.GetStatistics(cfg.IgnoreUnresolved, cfg.NonBlockedAsPassed)
 
 	err = report.RenderConsoleReport(stat, reportTime, cfg.WAFName, cfg.URL, cfg.Args, cfg.IgnoreUnresolved, logFormat)
@@ -238,6 +195,15 @@ func run(ctx context.Context, cfg *config.Config, logger *logrus.Logger) error {
 		return errors.Wrap(err, "couldn't export full report")
 	}
 
+	if harWriter != nil {
+		harFile := reportFile + ".har"
+		if err = harWriter.Save(harFile); err != nil {
+			return errors.Wrap(err, "couldn't export HAR file")
+		}
+
+		reportFiles = append(reportFiles, harFile)
+	}
+
 	for _, file := range reportFiles {
 		reportExt := strings.ToUpper(strings.Trim(filepath.Ext(file), "."))
 		logger.WithField("filename", file).Infof("Export %s full report", reportExt)
@@ -285,3 +251,196 @@ func run(ctx context.Context, cfg *config.Config, logger *logrus.Logger) error {
 
 	return nil
 }
+
+// loadTests loads the test cases and the request templates from the OpenAPI
+// file.
+func loadTests(ctx context.Context, cfg *config.Config, logger *logrus.Logger) (
+	testsDB *db.DB,
+	templates openapi.Templates,
+	router routers.Router,
+	err error,
+) {
+	if cfg.OpenAPIFile != "" {
+		var openapiDoc *openapi3.T
+
+		openapiDoc, router, err = openapi.LoadOpenAPISpec(ctx, cfg.OpenAPIFile)
+		if err != nil {
+			return nil, nil, nil, errors.Wrap(err, "couldn't load OpenAPI spec")
+		}
+		openapiDoc.Servers = append(openapiDoc.Servers, &openapi3.Server{
+			URL: cfg.URL,
+		})
+
+		templates, err = openapi.NewTemplates(openapiDoc, cfg.URL)
+		if err != nil {
+			return nil, nil, nil, errors.Wrap(err, "couldn't create templates from OpenAPI file")
+		}
+	}
+
+	testCasesPath := cfg.TestCasesPath
+	if testCasesPath == "" {
+		testCasesPath = db.EmbeddedTestCasesPath
+	}
+
+	logger.WithField("path", testCasesPath).Info("Test cases loading started")
+
+	testCases, err := db.LoadTestCases(cfg)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "loading test case")
+	}
+
+	logger.Info("Test cases loading finished")
+
+	testsDB, err = db.NewDB(testCases)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "couldn't create test cases DB")
+	}
+
+	logger.WithField("fp", testsDB.Hash).Info("Test cases fingerprint")
+
+	setTemplateSeed(cfg, testsDB, logger)
+
+	return testsDB, templates, router, nil
+}
+
+// setTemplateSeed sets a random seed of the payload templates if the seed
+// isn't set and the test cases contain templates. The generated seed is added
+// to the arguments shown in the reports, so the scan can be reproduced.
+func setTemplateSeed(cfg *config.Config, testsDB *db.DB, logger *logrus.Logger) {
+	for _, testCase := range testsDB.GetTestCases() {
+		if !testCase.Template {
+			continue
+		}
+
+		if cfg.TemplateSeed == 0 {
+			cfg.TemplateSeed = time.Now().UnixNano()
+
+			if !cfg.HideArgsInReport {
+				cfg.Args = append(cfg.Args, fmt.Sprintf("--templateSeed=%d", cfg.TemplateSeed))
+			}
+		}
+
+		logger.WithField("seed", cfg.TemplateSeed).Info("Payload templates are rendered with the seed")
+
+		return
+	}
+}
+
+// newScanner loads the test cases and creates the scanner. Before sending
+// the tests, it identifies the WAF, solves the JavaScript challenge and
+// collects the responses used to classify the test responses.
+func newScanner(ctx context.Context, cfg *config.Config, logger *logrus.Logger) (_ *scanner.Scanner, testsDB *db.DB, err error) {
+	testsDB, templates, router, err := loadTests(ctx, cfg, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !cfg.SkipWAFIdentification {
+		detector, err := waf_detector.NewWAFDetector(logger, cfg)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "couldn't create WAF waf_detector")
+		}
+
+		logger.Info("Try to identify WAF solution")
+
+		matches, err := detector.DetectWAF(ctx)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "couldn't detect")
+		}
+
+		if len(matches) > 0 {
+			for _, match := range matches {
+				logger.WithFields(logrus.Fields{
+					"solution": match.Detector.WAFName,
+					"vendor":   match.Detector.Vendor,
+					"checks":   strings.Join(match.Checks, "; "),
+				}).Info("WAF was identified")
+			}
+
+			picked, err := pickWAFDetector(matches, cfg.WAFDetector)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			logger.WithFields(logrus.Fields{
+				"solution": picked.WAFName,
+				"vendor":   picked.Vendor,
+			}).Info("WAF signature is used to detect blocked requests. Force enabling `--followCookies' and `--renewSession' options")
+
+			for _, match := range matches {
+				cfg.WAFLayers = append(cfg.WAFLayers, match.Detector)
+			}
+
+			cfg.CheckBlockFunc = picked.Check
+			cfg.FollowCookies = true
+			cfg.RenewSession = true
+			cfg.WAFName = fmt.Sprintf("%s (%s)", picked.WAFName, picked.Vendor)
+		} else {
+			logger.Info("WAF was not identified")
+		}
+	}
+
+	logger.WithField("http_client", cfg.HTTPClient).
+		Infof("%s is used as an HTTP client to make requests", cfg.HTTPClient)
+
+	s, err := scanner.New(logger, cfg, testsDB, templates, router, cfg.AddDebugHeader)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "couldn't create scanner")
+	}
+	// The returned scanner is nil on error, so the local one is closed
+	defer func() {
+		if err != nil {
+			s.Close()
+		}
+	}()
+
+	if cfg.HTTPClient != "chrome" {
+		isJsReuqired, err := s.CheckIfJavaScriptRequired(ctx)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "couldn't check if JavaScript is required to interact with the endpoint")
+		}
+
+		if isJsReuqired {
+			if !cfg.SolveJSChallenge {
+				return nil, nil, errors.New("JavaScript is required to interact with the endpoint. " +
+					"Use the '--solveJSChallenge' flag or '--httpClient=chrome'")
+			}
+
+			if err = s.SolveJSChallenge(ctx); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	if cfg.Calibrate {
+		if err = s.Calibrate(ctx); err != nil {
+			return nil, nil, errors.Wrap(err, "couldn't calibrate block detection")
+		}
+	}
+
+	if !cfg.SkipWAFBlockCheck {
+		err = s.WAFBlockCheck(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		logger.WithField("status", "skipped").Info("WAF pre-check")
+	}
+
+	if cfg.TarpitDetection != "" {
+		if err = s.CollectLatencyBaseline(ctx); err != nil {
+			return nil, nil, errors.Wrap(err, "couldn't collect benign latency")
+		}
+	}
+
+	if cfg.Baseline {
+		if err = s.CollectBaselines(ctx); err != nil {
+			return nil, nil, errors.Wrap(err, "couldn't collect baseline responses")
+		}
+	}
+
+	s.CheckGRPCAvailability(ctx)
+	s.CheckGraphQLAvailability(ctx)
+
+	return s, testsDB, nil
+}
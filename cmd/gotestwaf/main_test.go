@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/wallarm/gotestwaf/internal/config"
+	"github.com/wallarm/gotestwaf/internal/db"
+)
+
+func TestNewScannerPreCheckFailed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	}))
+	defer srv.Close()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	cfg := &config.Config{
+		URL:                   srv.URL,
+		HTTPClient:            "gohttp",
+		BlockStatusCodes:      []int{403},
+		PassStatusCodes:       []int{200, 404},
+		SkipWAFIdentification: true,
+		IdleConnTimeout:       2,
+		MaxIdleConns:          2,
+		Workers:               1,
+		LogLevel:              "info",
+	}
+
+	s, _, err := newScanner(context.Background(), cfg, logger)
+	if err == nil {
+		t.Fatalf("scanner is created for the target without WAF")
+	}
+	if s != nil {
+		t.Errorf("scanner is returned with the error")
+	}
+	if !strings.Contains(err.Error(), "WAF was not detected") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSetTemplateSeed(t *testing.T) {
+	testsDB, err := db.NewDB([]*db.Case{{
+		Payloads:     []string{"{{rand.alpha 8}}"},
+		Encoders:     []string{"Plain"},
+		Placeholders: []*db.Placeholder{{Name: "URLParam"}},
+		Set:          "owasp",
+		Name:         "template",
+		Template:     true,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	cfg := &config.Config{Args: []string{"--url=http://example.com"}}
+	setTemplateSeed(cfg, testsDB, logger)
+
+	if cfg.TemplateSeed == 0 {
+		t.Fatalf("template seed isn't set")
+	}
+	if want := fmt.Sprintf("--templateSeed=%d", cfg.TemplateSeed); cfg.Args[len(cfg.Args)-1] != want {
+		t.Errorf("got args %v, want the last one %s", cfg.Args, want)
+	}
+
+	cfg = &config.Config{TemplateSeed: 42}
+	setTemplateSeed(cfg, testsDB, logger)
+
+	if cfg.TemplateSeed != 42 || len(cfg.Args) != 0 {
+		t.Errorf("the seed set by the user is changed: seed %d, args %v", cfg.TemplateSeed, cfg.Args)
+	}
+}
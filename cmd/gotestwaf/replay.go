@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/wallarm/gotestwaf/internal/config"
+	"github.com/wallarm/gotestwaf/internal/version"
+)
+
+const replayCommand = "replay"
+
+// replay sends the test selected by the debug header hash or by
+// <set>/<case>/<payload index> once and prints the requests, the responses
+// and their classification.
+func replay(ctx context.Context, cfg *config.Config, logger *logrus.Logger, test string) error {
+	logger.WithField("version", version.Version).Info("GoTestWAF started")
+
+	// The test is sent regardless of whether the WAF blocks the pre-check
+	// request
+	cfg.SkipWAFBlockCheck = true
+
+	s, _, err := newScanner(ctx, cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	return s.Replay(ctx, test, os.Stdout)
+}
@@ -0,0 +1,573 @@
+package auth
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/wallarm/gotestwaf/internal/config"
+	"github.com/wallarm/gotestwaf/internal/helpers"
+	"github.com/wallarm/gotestwaf/pkg/dnscache"
+)
+
+const (
+	loginRepeatAttempts = 3
+)
+
+var templateFuncs = template.FuncMap{
+	"env": os.Getenv,
+}
+
+type step struct {
+	method  string
+	url     *template.Template
+	headers map[string]*template.Template
+	body    *template.Template
+	extract []*extractor
+}
+
+type injection struct {
+	header         string
+	cookie         string
+	value          *template.Template
+	sessionCookies bool
+}
+
+type session struct {
+	vars    map[string]string
+	cookies []*http.Cookie
+	created time.Time
+}
+
+// Authenticator performs the configured authentication flow and adds the
+// obtained credentials to requests. It is shared between all clients, so the
+// session is renewed only once for all workers.
+type Authenticator struct {
+	client     *http.Client
+	headers    map[string]string
+	hostHeader string
+	baseURL    *url.URL
+
+	steps  []*step
+	inject []*injection
+
+	refreshInterval    time.Duration
+	refreshOnStatus    []int
+	refreshEachRequest bool
+
+	mu      sync.Mutex
+	session *session
+}
+
+// New creates an Authenticator from the auth section of the config. If the
+// section is absent but the --followCookies and --renewSession options are
+// set, the implicit flow is used: cookies are fetched from the target URL
+// before each request. If neither is configured, nil is returned.
+func New(cfg *config.Config, dnsResolver *dnscache.Resolver) (*Authenticator, error) {
+	authCfg := cfg.Auth
+	if authCfg == nil {
+		if !cfg.FollowCookies || !cfg.RenewSession {
+			return nil, nil
+		}
+
+		authCfg = implicitSessionConfig(cfg.URL)
+	}
+
+	if len(authCfg.Steps) == 0 {
+		return nil, errors.New("auth: at least one step must be configured")
+	}
+
+	baseURL, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't parse URL")
+	}
+
+	client, err := newHTTPClient(cfg, dnsResolver)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Authenticator{
+		client:             client,
+		headers:            helpers.DeepCopyMap(cfg.HTTPHeaders),
+		hostHeader:         cfg.HTTPHeaders["Host"],
+		baseURL:            baseURL,
+		refreshInterval:    authCfg.RefreshInterval,
+		refreshOnStatus:    authCfg.RefreshOnStatus,
+		refreshEachRequest: authCfg.RefreshEachRequest,
+	}
+
+	for i, s := range authCfg.Steps {
+		st, err := newStep(&s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "auth: couldn't parse step #%d", i+1)
+		}
+
+		a.steps = append(a.steps, st)
+	}
+
+	for i, inj := range authCfg.Inject {
+		in, err := newInjection(&inj)
+		if err != nil {
+			return nil, errors.Wrapf(err, "auth: couldn't parse inject #%d", i+1)
+		}
+
+		a.inject = append(a.inject, in)
+	}
+
+	return a, nil
+}
+
+// implicitSessionConfig returns the flow that replaces the old --renewSession
+// behaviour: a GET request to the target before each test, whose cookies are
+// passed with the test request.
+func implicitSessionConfig(targetURL string) *config.AuthConfig {
+	return &config.AuthConfig{
+		Steps: []config.AuthStep{
+			{Method: http.MethodGet, URL: targetURL},
+		},
+		Inject: []config.AuthInject{
+			{SessionCookies: true},
+		},
+		RefreshEachRequest: true,
+	}
+}
+
+func newHTTPClient(cfg *config.Config, dnsResolver *dnscache.Resolver) (*http.Client, error) {
+	tlsConf, err := helpers.NewTLSConfig(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't create TLS config")
+	}
+
+	tr := &http.Transport{
+		TLSClientConfig:     tlsConf,
+		IdleConnTimeout:     time.Duration(cfg.IdleConnTimeout) * time.Second,
+		MaxIdleConns:        cfg.MaxIdleConns,
+		MaxIdleConnsPerHost: cfg.MaxIdleConns, // net.http hardcodes DefaultMaxIdleConnsPerHost to 2!
+	}
+
+	if dnsResolver != nil {
+		tr.DialContext = dnscache.DialFunc(dnsResolver, nil)
+	}
+
+	if cfg.Proxy != "" {
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't parse proxy URL")
+		}
+
+		tr.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	client := &http.Client{
+		Transport: tr,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if cfg.MaxRedirects == 0 {
+				return http.ErrUseLastResponse
+			}
+
+			if len(via) > cfg.MaxRedirects {
+				return errors.New("max redirect number exceeded")
+			}
+
+			return nil
+		},
+	}
+
+	return client, nil
+}
+
+func newTemplate(text string) (*template.Template, error) {
+	return template.New("").Option("missingkey=error").Funcs(templateFuncs).Parse(text)
+}
+
+func newStep(cfg *config.AuthStep) (*step, error) {
+	var err error
+
+	s := &step{
+		method:  strings.ToUpper(cfg.Method),
+		headers: make(map[string]*template.Template, len(cfg.Headers)),
+	}
+
+	if s.method == "" {
+		s.method = http.MethodGet
+	}
+
+	if cfg.URL == "" {
+		return nil, errors.New("empty URL")
+	}
+
+	s.url, err = newTemplate(cfg.URL)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't parse URL template")
+	}
+
+	s.body, err = newTemplate(cfg.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't parse body template")
+	}
+
+	for header, value := range cfg.Headers {
+		s.headers[header], err = newTemplate(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't parse template of the %s header", header)
+		}
+	}
+
+	for i := range cfg.Extract {
+		e, err := newExtractor(&cfg.Extract[i])
+		if err != nil {
+			return nil, err
+		}
+
+		s.extract = append(s.extract, e)
+	}
+
+	return s, nil
+}
+
+func newInjection(cfg *config.AuthInject) (*injection, error) {
+	in := &injection{
+		header:         cfg.Header,
+		cookie:         cfg.Cookie,
+		sessionCookies: cfg.SessionCookies,
+	}
+
+	if in.header == "" && in.cookie == "" {
+		if in.sessionCookies {
+			return in, nil
+		}
+
+		return nil, errors.New("header, cookie or sessionCookies must be set")
+	}
+
+	if in.header != "" && in.cookie != "" {
+		return nil, errors.New("only one of header and cookie can be set")
+	}
+
+	value, err := newTemplate(cfg.Value)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't parse value template")
+	}
+
+	in.value = value
+
+	return in, nil
+}
+
+func execute(t *template.Template, vars map[string]string) (string, error) {
+	var sb strings.Builder
+
+	err := t.Execute(&sb, vars)
+	if err != nil {
+		return "", err
+	}
+
+	return sb.String(), nil
+}
+
+// Do sends the request with the credentials of the current session by send.
+// If the response status code is one of refreshOnStatus, the session is
+// renewed and the request is sent again. The session is obtained before send
+// is called, so send can measure the response time of the request only.
+func (a *Authenticator) Do(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	ctx := req.Context()
+
+	s, err := a.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r := req.Clone(ctx)
+	if err = a.apply(s, r); err != nil {
+		return nil, err
+	}
+
+	resp, err := send(r)
+	if err != nil || !a.isRefreshStatus(resp.StatusCode) {
+		return resp, err
+	}
+
+	// The request body was already read and can't be sent again
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil
+	}
+
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	a.invalidate(s)
+
+	s, err = a.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r = req.Clone(ctx)
+	if req.GetBody != nil {
+		r.Body, err = req.GetBody()
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't copy request body")
+		}
+	}
+
+	if err = a.apply(s, r); err != nil {
+		return nil, err
+	}
+
+	return send(r)
+}
+
+// Invoke calls fn with the credentials of the current session rendered as
+// headers. It is used by the clients that don't send HTTP requests directly
+// (e.g. gRPC). If fn returns one of refreshOnStatus status codes, the session
+// is renewed and fn is called once more.
+func (a *Authenticator) Invoke(ctx context.Context, fn func(headers http.Header) (statusCode int, err error)) error {
+	s, err := a.current(ctx)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		headers, err := a.render(s)
+		if err != nil {
+			return err
+		}
+
+		statusCode, err := fn(headers)
+		if err != nil || attempt > 0 || !a.isRefreshStatus(statusCode) {
+			return err
+		}
+
+		a.invalidate(s)
+
+		s, err = a.current(ctx)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (a *Authenticator) isRefreshStatus(statusCode int) bool {
+	return !a.refreshEachRequest && slices.Contains(a.refreshOnStatus, statusCode)
+}
+
+// current returns the active session, performing a login if there is no
+// session yet or the current one has expired.
+func (a *Authenticator) current(ctx context.Context) (*session, error) {
+	if a.refreshEachRequest {
+		return a.login(ctx)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.session != nil {
+		if a.refreshInterval == 0 || time.Since(a.session.created) < a.refreshInterval {
+			return a.session, nil
+		}
+	}
+
+	s, err := a.login(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	a.session = s
+
+	return s, nil
+}
+
+// invalidate drops the session if it hasn't been renewed by another worker yet.
+func (a *Authenticator) invalidate(s *session) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.session == s {
+		a.session = nil
+	}
+}
+
+func (a *Authenticator) login(ctx context.Context) (*session, error) {
+	var returnErr error
+
+	for i := 0; i < loginRepeatAttempts; i++ {
+		s, err := a.runSteps(ctx)
+		if err == nil {
+			return s, nil
+		}
+
+		returnErr = err
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return nil, errors.Wrap(returnErr, "couldn't authenticate")
+}
+
+func (a *Authenticator) runSteps(ctx context.Context) (*session, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't create cookie jar")
+	}
+
+	client := &http.Client{
+		Transport:     a.client.Transport,
+		CheckRedirect: a.client.CheckRedirect,
+		Jar:           jar,
+	}
+
+	vars := make(map[string]string)
+
+	for i, st := range a.steps {
+		err = a.runStep(ctx, client, st, vars)
+		if err != nil {
+			return nil, errors.Wrapf(err, "step #%d", i+1)
+		}
+	}
+
+	return &session{
+		vars:    vars,
+		cookies: jar.Cookies(a.baseURL),
+		created: time.Now(),
+	}, nil
+}
+
+func (a *Authenticator) runStep(ctx context.Context, client *http.Client, st *step, vars map[string]string) error {
+	rawURL, err := execute(st.url, vars)
+	if err != nil {
+		return errors.Wrap(err, "couldn't render URL")
+	}
+
+	stepURL, err := a.baseURL.Parse(rawURL)
+	if err != nil {
+		return errors.Wrap(err, "couldn't parse URL")
+	}
+
+	body, err := execute(st.body, vars)
+	if err != nil {
+		return errors.Wrap(err, "couldn't render body")
+	}
+
+	var bodyReader io.Reader
+	if body != "" {
+		bodyReader = strings.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, st.method, stepURL.String(), bodyReader)
+	if err != nil {
+		return errors.Wrap(err, "couldn't create request")
+	}
+
+	for header, value := range a.headers {
+		req.Header.Set(header, value)
+	}
+	req.Host = a.hostHeader
+
+	for header, t := range st.headers {
+		value, err := execute(t, vars)
+		if err != nil {
+			return errors.Wrapf(err, "couldn't render the %s header", header)
+		}
+
+		req.Header.Set(header, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "sending http request")
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "reading response body")
+	}
+
+	for _, e := range st.extract {
+		value, err := e.extract(resp, respBody, client.Jar.Cookies(req.URL))
+		if err != nil {
+			return errors.Wrapf(err, "couldn't extract %q (HTTP status: %d)", e.name, resp.StatusCode)
+		}
+
+		vars[e.name] = value
+	}
+
+	return nil
+}
+
+// render returns the headers with the session credentials. Cookies are
+// combined into the single Cookie header.
+func (a *Authenticator) render(s *session) (http.Header, error) {
+	headers := make(http.Header)
+
+	cookies, err := a.renderTo(s, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cookies) > 0 {
+		pairs := make([]string, 0, len(cookies))
+		for _, c := range cookies {
+			pairs = append(pairs, c.String())
+		}
+
+		headers.Set("Cookie", strings.Join(pairs, "; "))
+	}
+
+	return headers, nil
+}
+
+func (a *Authenticator) apply(s *session, req *http.Request) error {
+	cookies, err := a.renderTo(s, req.Header)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+
+	return nil
+}
+
+func (a *Authenticator) renderTo(s *session, headers http.Header) ([]*http.Cookie, error) {
+	var cookies []*http.Cookie
+
+	for _, in := range a.inject {
+		if in.sessionCookies {
+			for _, c := range s.cookies {
+				cookies = append(cookies, &http.Cookie{Name: c.Name, Value: c.Value})
+			}
+		}
+
+		if in.value == nil {
+			continue
+		}
+
+		value, err := execute(in.value, s.vars)
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't render injected value")
+		}
+
+		if in.header != "" {
+			headers.Set(in.header, value)
+		} else {
+			cookies = append(cookies, &http.Cookie{Name: in.cookie, Value: value})
+		}
+	}
+
+	return cookies, nil
+}
@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/wallarm/gotestwaf/internal/config"
+)
+
+func TestParseJSONPath(t *testing.T) {
+	testCases := []struct {
+		path  string
+		body  string
+		want  string
+		isBad bool
+	}{
+		{path: "$.token", body: `{"token":"abc"}`, want: "abc"},
+		{path: "token", body: `{"token":"abc"}`, want: "abc"},
+		{path: "$.data.items[1].id", body: `{"data":{"items":[{"id":1},{"id":12345678901234}]}}`, want: "12345678901234"},
+		{path: "$['a.b'].c", body: `{"a.b":{"c":true}}`, want: "true"},
+		{path: "$.obj", body: `{"obj":{"k":"v"}}`, want: `{"k":"v"}`},
+		{path: "$.missing", body: `{"token":"abc"}`, isBad: true},
+		{path: "$.items[5]", body: `{"items":[]}`, isBad: true},
+		{path: "$.token", body: `not json`, isBad: true},
+		{path: "$[abc]", isBad: true},
+		{path: "$.a[0", isBad: true},
+		{path: "$", isBad: true},
+	}
+
+	for _, tc := range testCases {
+		path, err := parseJSONPath(tc.path)
+		if err == nil {
+			var got string
+			got, err = evalJSONPath([]byte(tc.body), path)
+			if err == nil && got != tc.want {
+				t.Errorf("%s: got %q, want %q", tc.path, got, tc.want)
+			}
+		}
+
+		if tc.isBad && err == nil {
+			t.Errorf("%s: expected error", tc.path)
+		}
+		if !tc.isBad && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.path, err)
+		}
+	}
+}
+
+func TestAuthenticator(t *testing.T) {
+	var logins atomic.Int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1"})
+			fmt.Fprint(w, `<input name="csrf" value="csrf-token">`)
+			return
+		}
+
+		c, err := r.Cookie("session")
+		if err != nil || c.Value != "s1" || r.FormValue("csrf") != "csrf-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		n := logins.Add(1)
+		fmt.Fprintf(w, `{"data":{"token":"token-%d"}}`, n)
+	})
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		// the first issued token is considered expired
+		if r.Header.Get("Authorization") != fmt.Sprintf("Bearer token-%d", logins.Load()) || logins.Load() < 2 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		c, err := r.Cookie("session")
+		if err != nil || c.Value != "s1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		fmt.Fprint(w, "ok")
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	cfg := &config.Config{
+		URL:          server.URL,
+		MaxRedirects: 10,
+		Auth: &config.AuthConfig{
+			Steps: []config.AuthStep{
+				{
+					URL: "/login",
+					Extract: []config.AuthExtract{
+						{Var: "csrf", Regex: `name="csrf" value="([^"]+)"`},
+					},
+				},
+				{
+					Method:  "post",
+					URL:     "/login",
+					Headers: map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+					Body:    "csrf={{.csrf}}",
+					Extract: []config.AuthExtract{
+						{Var: "token", JSONPath: "$.data.token"},
+					},
+				},
+			},
+			Inject: []config.AuthInject{
+				{Header: "Authorization", Value: "Bearer {{.token}}"},
+				{SessionCookies: true},
+			},
+			RefreshOnStatus: []int{http.StatusUnauthorized},
+		},
+	}
+
+	a, err := New(cfg, nil)
+	if err != nil {
+		t.Fatalf("couldn't create authenticator: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL+"/api", strings.NewReader("body"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := a.Do(req, http.DefaultClient.Do)
+	if err != nil {
+		t.Fatalf("couldn't send request: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if n := logins.Load(); n != 2 {
+		t.Errorf("got %d logins, want 2", n)
+	}
+
+	var gotHeaders http.Header
+	err = a.Invoke(context.Background(), func(headers http.Header) (int, error) {
+		gotHeaders = headers
+		return http.StatusOK, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := gotHeaders.Get("Authorization"); got != "Bearer token-2" {
+		t.Errorf("got Authorization %q, want %q", got, "Bearer token-2")
+	}
+	if got := gotHeaders.Get("Cookie"); got != "session=s1" {
+		t.Errorf("got Cookie %q, want %q", got, "session=s1")
+	}
+	if n := logins.Load(); n != 2 {
+		t.Errorf("session wasn't reused: got %d logins, want 2", n)
+	}
+}
+
+func TestImplicitSession(t *testing.T) {
+	cfg := &config.Config{URL: "http://example.com"}
+
+	a, err := New(cfg, nil)
+	if err != nil || a != nil {
+		t.Fatalf("expected no authenticator, got %v, %v", a, err)
+	}
+
+	cfg.FollowCookies = true
+	cfg.RenewSession = true
+
+	a, err = New(cfg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a == nil || !a.refreshEachRequest || len(a.steps) != 1 {
+		t.Fatal("expected the implicit session flow")
+	}
+}
+
+func TestDoSendsAfterLogin(t *testing.T) {
+	var logins atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			logins.Add(1)
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1"})
+		}
+	}))
+	defer server.Close()
+
+	a, err := New(&config.Config{URL: server.URL, FollowCookies: true, RenewSession: true}, nil)
+	if err != nil {
+		t.Fatalf("couldn't create authenticator: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL+"/api", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The response time measured by send mustn't include the login
+	resp, err := a.Do(req, func(r *http.Request) (*http.Response, error) {
+		if logins.Load() != 1 {
+			t.Errorf("request is sent before the session is obtained")
+		}
+		if c, err := r.Cookie("session"); err != nil || c.Value != "s1" {
+			t.Errorf("request is sent without the session cookie")
+		}
+
+		return http.DefaultClient.Do(r)
+	})
+	if err != nil {
+		t.Fatalf("couldn't send request: %v", err)
+	}
+	resp.Body.Close()
+}
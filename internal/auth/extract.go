@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/wallarm/gotestwaf/internal/config"
+)
+
+type extractor struct {
+	name     string
+	header   string
+	cookie   string
+	jsonPath []any
+	re       *regexp.Regexp
+}
+
+func newExtractor(cfg *config.AuthExtract) (*extractor, error) {
+	e := &extractor{
+		name:   cfg.Var,
+		header: cfg.Header,
+		cookie: cfg.Cookie,
+	}
+
+	if e.name == "" {
+		return nil, errors.New("extract: empty variable name")
+	}
+
+	sources := 0
+	for _, s := range []string{cfg.Header, cfg.Cookie, cfg.JSONPath} {
+		if s != "" {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return nil, errors.Errorf("extract %q: only one of header, cookie and jsonPath can be set", e.name)
+	}
+
+	if cfg.JSONPath != "" {
+		path, err := parseJSONPath(cfg.JSONPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "extract %q", e.name)
+		}
+
+		e.jsonPath = path
+	}
+
+	if cfg.Regex != "" {
+		re, err := regexp.Compile(cfg.Regex)
+		if err != nil {
+			return nil, errors.Wrapf(err, "extract %q: couldn't compile regex", e.name)
+		}
+
+		e.re = re
+	}
+
+	return e, nil
+}
+
+func (e *extractor) extract(resp *http.Response, body []byte, cookies []*http.Cookie) (string, error) {
+	var value string
+
+	switch {
+	case e.header != "":
+		value = resp.Header.Get(e.header)
+		if value == "" {
+			return "", errors.Errorf("header %s not found", e.header)
+		}
+
+	case e.cookie != "":
+		found := false
+		for _, c := range append(resp.Cookies(), cookies...) {
+			if c.Name == e.cookie {
+				value = c.Value
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", errors.Errorf("cookie %s not found", e.cookie)
+		}
+
+	case e.jsonPath != nil:
+		v, err := evalJSONPath(body, e.jsonPath)
+		if err != nil {
+			return "", err
+		}
+		value = v
+
+	default:
+		value = string(body)
+	}
+
+	if e.re == nil {
+		return value, nil
+	}
+
+	match := e.re.FindStringSubmatch(value)
+	if match == nil {
+		return "", errors.Errorf("regex %q didn't match", e.re.String())
+	}
+
+	if len(match) > 1 {
+		return match[1], nil
+	}
+
+	return match[0], nil
+}
+
+// parseJSONPath parses a simple JSONPath expression. Only child access is
+// supported: $.a.b, $['a'].b, $.a[0].b.
+func parseJSONPath(path string) ([]any, error) {
+	var elems []any
+
+	rest := strings.TrimPrefix(strings.TrimSpace(path), "$")
+
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, errors.Errorf("bad JSONPath %q: empty key", path)
+			}
+
+			elems = append(elems, rest[:end])
+			rest = rest[end:]
+
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, errors.Errorf("bad JSONPath %q: unclosed bracket", path)
+			}
+
+			inner := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				elems = append(elems, inner[1:len(inner)-1])
+				continue
+			}
+
+			index, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, errors.Errorf("bad JSONPath %q: bad index %q", path, inner)
+			}
+
+			elems = append(elems, index)
+
+		default:
+			if len(elems) > 0 {
+				return nil, errors.Errorf("bad JSONPath %q: unexpected %q", path, rest[0])
+			}
+
+			// a path without leading "$." is treated as a key
+			rest = "." + rest
+		}
+	}
+
+	if len(elems) == 0 {
+		return nil, errors.Errorf("bad JSONPath %q: empty path", path)
+	}
+
+	return elems, nil
+}
+
+func evalJSONPath(body []byte, path []any) (string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var node any
+	if err := decoder.Decode(&node); err != nil {
+		return "", errors.Wrap(err, "couldn't parse JSON body")
+	}
+
+	for _, elem := range path {
+		switch key := elem.(type) {
+		case string:
+			obj, ok := node.(map[string]any)
+			if !ok {
+				return "", errors.Errorf("JSON key %q not found", key)
+			}
+
+			node, ok = obj[key]
+			if !ok {
+				return "", errors.Errorf("JSON key %q not found", key)
+			}
+
+		case int:
+			arr, ok := node.([]any)
+			if !ok || key < 0 || key >= len(arr) {
+				return "", errors.Errorf("JSON index %d not found", key)
+			}
+
+			node = arr[key]
+		}
+	}
+
+	switch v := node.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case nil:
+		return "", errors.New("JSON value is null")
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+
+		return string(b), nil
+	}
+}
@@ -0,0 +1,54 @@
+package config
+
+import "time"
+
+// AuthConfig describes a scripted authentication flow. The steps are sent
+// one after another, values extracted from the responses are stored as
+// variables and then injected into every request sent by GoTestWAF.
+type AuthConfig struct {
+	Steps  []AuthStep   `mapstructure:"steps"`
+	Inject []AuthInject `mapstructure:"inject"`
+
+	// RefreshInterval forces a new login after the given time. If it is
+	// zero, the session is refreshed only on RefreshOnStatus responses.
+	RefreshInterval time.Duration `mapstructure:"refreshInterval"`
+	// RefreshOnStatus contains HTTP status codes which indicate that the
+	// session has expired. The request will be resent once after a new login.
+	RefreshOnStatus []int `mapstructure:"refreshOnStatus"`
+	// RefreshEachRequest makes a new login before each request.
+	RefreshEachRequest bool `mapstructure:"refreshEachRequest"`
+}
+
+// AuthStep is a single request of the authentication flow. URL, headers and
+// body are Go templates, the variables extracted on the previous steps are
+// available as {{.name}}, environment variables as {{env "NAME"}}.
+type AuthStep struct {
+	Method  string            `mapstructure:"method"`
+	URL     string            `mapstructure:"url"`
+	Headers map[string]string `mapstructure:"headers"`
+	Body    string            `mapstructure:"body"`
+
+	Extract []AuthExtract `mapstructure:"extract"`
+}
+
+// AuthExtract describes how to get a variable from the step response. The
+// value is taken from the header, the cookie or the JSON body field. If none
+// of them is set, the whole body is used. If Regex is set, it is applied to
+// the value and the first capturing group (or the whole match) is used.
+type AuthExtract struct {
+	Var      string `mapstructure:"var"`
+	Header   string `mapstructure:"header"`
+	Cookie   string `mapstructure:"cookie"`
+	JSONPath string `mapstructure:"jsonPath"`
+	Regex    string `mapstructure:"regex"`
+}
+
+// AuthInject describes how to add the session to requests: as a header, as a
+// cookie, or by passing all cookies received during the login. Value is a
+// template with the same variables as AuthStep.
+type AuthInject struct {
+	Header         string `mapstructure:"header"`
+	Cookie         string `mapstructure:"cookie"`
+	Value          string `mapstructure:"value"`
+	SessionCookies bool   `mapstructure:"sessionCookies"`
+}
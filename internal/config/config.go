@@ -12,17 +12,33 @@ type Config struct {
 	OpenAPIFile string `mapstructure:"openapiFile"`
 
 	// Test cases settings
-	TestCase      string `mapstructure:"testCase"`
-	TestCasesPath string `mapstructure:"testCasesPath"`
-	TestSet       string `mapstructure:"testSet"`
+	TestCase          string   `mapstructure:"testCase"`
+	TestCasesPath     string   `mapstructure:"testCasesPath"`
+	AddTestCasesPaths []string `mapstructure:"addTestCasesPath"`
+	TestSet           string   `mapstructure:"testSet"`
+	Include           []string `mapstructure:"include"`
+	Exclude           []string `mapstructure:"exclude"`
+	TemplateVars      []string `mapstructure:"templateVar"`
+	TemplateSeed      int64    `mapstructure:"templateSeed"`
 
 	// HTTP client settings
 	HTTPClient     string `mapstructure:"httpClient"`
 	TLSVerify      bool   `mapstructure:"tlsVerify"`
+	TLSClientCert  string `mapstructure:"tlsClientCert"`
+	TLSClientKey   string `mapstructure:"tlsClientKey"`
+	TLSCA          string `mapstructure:"tlsCA"`
+	TLSServerName  string `mapstructure:"tlsServerName"`
+	TLSMinVersion  string `mapstructure:"tlsMinVersion"`
+	TLSMaxVersion  string `mapstructure:"tlsMaxVersion"`
 	Proxy          string `mapstructure:"proxy"`
 	AddHeader      string `mapstructure:"addHeader"`
 	AddDebugHeader bool   `mapstructure:"addDebugHeader"`
 
+	// Response read limits
+	MaxResponseSize      int  `mapstructure:"maxResponseSize"`
+	ResponseReadTimeout  int  `mapstructure:"responseReadTimeout"`
+	StreamFirstEventOnly bool `mapstructure:"streamFirstEventOnly"`
+
 	// GoHTTP client only settings
 	MaxIdleConns    int  `mapstructure:"maxIdleConns"`
 	MaxRedirects    int  `mapstructure:"maxRedirects"`
@@ -30,14 +46,22 @@ type Config struct {
 	FollowCookies   bool `mapstructure:"followCookies"`
 	RenewSession    bool `mapstructure:"renewSession"`
 
+	// JavaScript challenge settings
+	SolveJSChallenge   bool `mapstructure:"solveJSChallenge"`
+	JSChallengeTimeout int  `mapstructure:"jsChallengeTimeout"`
+
 	// Performance settings
-	Workers     int `mapstructure:"workers"`
-	RandomDelay int `mapstructure:"randomDelay"`
-	SendDelay   int `mapstructure:"sendDelay"`
+	Workers     int    `mapstructure:"workers"`
+	RandomDelay int    `mapstructure:"randomDelay"`
+	SendDelay   int    `mapstructure:"sendDelay"`
+	DryRun      bool   `mapstructure:"dryRun"`
+	DryRunDump  string `mapstructure:"dryRunDump"`
 
 	// Analysis settings
 	SkipWAFBlockCheck     bool   `mapstructure:"skipWAFBlockCheck"`
 	SkipWAFIdentification bool   `mapstructure:"skipWAFIdentification"`
+	WAFDetectorsPath      string `mapstructure:"wafDetectorsPath"`
+	WAFDetector           string `mapstructure:"wafDetector"`
 	BlockStatusCodes      []int  `mapstructure:"blockStatusCodes"`
 	PassStatusCodes       []int  `mapstructure:"passStatusCodes"`
 	BlockRegex            string `mapstructure:"blockRegex"`
@@ -45,6 +69,10 @@ type Config struct {
 	NonBlockedAsPassed    bool   `mapstructure:"nonBlockedAsPassed"`
 	IgnoreUnresolved      bool   `mapstructure:"ignoreUnresolved"`
 	BlockConnReset        bool   `mapstructure:"blockConnReset"`
+	Calibrate             bool   `mapstructure:"calibrate"`
+	Baseline              bool   `mapstructure:"baseline"`
+	TarpitDetection       string `mapstructure:"tarpitDetection"`
+	TarpitTimeout         int    `mapstructure:"tarpitTimeout"`
 
 	// Report settings
 	WAFName          string   `mapstructure:"wafName"`
@@ -55,14 +83,22 @@ type Config struct {
 	NoEmailReport    bool     `mapstructure:"noEmailReport"`
 	Email            string   `mapstructure:"email"`
 	HideArgsInReport bool     `mapstructure:"hideArgsInReport"`
+	IncludeEvidence  bool     `mapstructure:"includeEvidence"`
+	EvidenceBodySize int      `mapstructure:"evidenceBodySize"`
 
 	// config.yaml
 	HTTPHeaders map[string]string `mapstructure:"headers"`
+	Auth        *AuthConfig       `mapstructure:"auth"`
+	Signing     *SigningConfig    `mapstructure:"signing"`
+	Rules       []*ResponseRule   `mapstructure:"rules"`
 
 	// Other settings
 	LogLevel string `mapstructure:"logLevel"`
 
 	CheckBlockFunc detectors.Check
+	// WAFLayers contains all identified WAFs, they are used to attribute
+	// blocked requests
+	WAFLayers []*detectors.Detector
 
 	Args []string
 }
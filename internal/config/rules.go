@@ -0,0 +1,51 @@
+package config
+
+import "time"
+
+// ResponseRule classifies a response as blocked or passed if the response
+// matches the condition. Rules are checked in order, the first matched rule
+// is used and reported by its name.
+type ResponseRule struct {
+	Name string `mapstructure:"name"`
+	// Action is the decision made by the rule: block or pass.
+	Action string         `mapstructure:"action"`
+	Match  *RuleCondition `mapstructure:"match"`
+}
+
+// RuleCondition is a node of the rule condition tree. It must contain
+// exactly one condition or one of the and, or and not operators.
+type RuleCondition struct {
+	And []*RuleCondition `mapstructure:"and"`
+	Or  []*RuleCondition `mapstructure:"or"`
+	Not *RuleCondition   `mapstructure:"not"`
+
+	// Status matches any of the listed status codes.
+	Status []int `mapstructure:"status"`
+	// Header matches if any value of the header matches the regex. An empty
+	// regex matches if the header is present.
+	Header *RuleHeader `mapstructure:"header"`
+	// Body is a regex for the response body.
+	Body string `mapstructure:"body"`
+	// BodySize matches the body length in bytes.
+	BodySize *RuleSizeRange `mapstructure:"bodySize"`
+	// ResponseTime matches the time elapsed from sending the request until
+	// the response body was read.
+	ResponseTime *RuleDurationRange `mapstructure:"responseTime"`
+}
+
+type RuleHeader struct {
+	Name  string `mapstructure:"name"`
+	Regex string `mapstructure:"regex"`
+}
+
+// RuleSizeRange is an inclusive range, zero Max means no upper bound.
+type RuleSizeRange struct {
+	Min int `mapstructure:"min"`
+	Max int `mapstructure:"max"`
+}
+
+// RuleDurationRange is an inclusive range, zero Max means no upper bound.
+type RuleDurationRange struct {
+	Min time.Duration `mapstructure:"min"`
+	Max time.Duration `mapstructure:"max"`
+}
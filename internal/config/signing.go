@@ -0,0 +1,41 @@
+package config
+
+// SigningConfig describes how requests are signed. Signing is the last step
+// before a request is sent, so the signature covers the placed payload.
+type SigningConfig struct {
+	// Type is the signing algorithm: hmac or aws-sigv4.
+	Type string `mapstructure:"type"`
+
+	HMAC HMACSigningConfig `mapstructure:"hmac"`
+	AWS  AWSSigningConfig  `mapstructure:"aws"`
+}
+
+// HMACSigningConfig describes HMAC-SHA256 header signing. The string to sign
+// is built from Components joined with Separator. Supported components:
+// method, host, path, query, body, body-sha256, timestamp and header:<name>.
+type HMACSigningConfig struct {
+	Secret    string `mapstructure:"secret"`
+	SecretEnv string `mapstructure:"secretEnv"`
+
+	Components []string `mapstructure:"components"`
+	Separator  string   `mapstructure:"separator"`
+	SortQuery  bool     `mapstructure:"sortQuery"`
+
+	Header   string `mapstructure:"header"`
+	Prefix   string `mapstructure:"prefix"`
+	Encoding string `mapstructure:"encoding"`
+
+	TimestampHeader string `mapstructure:"timestampHeader"`
+	TimestampFormat string `mapstructure:"timestampFormat"`
+}
+
+// AWSSigningConfig describes AWS Signature Version 4 signing. Empty
+// credentials are taken from the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// AWS_SESSION_TOKEN environment variables.
+type AWSSigningConfig struct {
+	Region          string `mapstructure:"region"`
+	Service         string `mapstructure:"service"`
+	AccessKeyID     string `mapstructure:"accessKeyID"`
+	SecretAccessKey string `mapstructure:"secretAccessKey"`
+	SessionToken    string `mapstructure:"sessionToken"`
+}
@@ -44,7 +44,7 @@ func NewDB(tests []*Case) (*DB, error) {
 			db.counters[test.Set][test.Name] = map[string]int{}
 		}
 
-		db.NumberOfTests += uint(len(test.Payloads) * len(test.Encoders) * len(test.Placeholders))
+		db.NumberOfTests += uint(test.NumberOfTests())
 
 		hashSums = append(hashSums, test.Hash())
 	}
@@ -4,6 +4,7 @@ import (
 	"encoding/csv"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/wallarm/gotestwaf/internal/payload/encoder"
 )
@@ -27,6 +28,12 @@ func (db *DB) ExportPayloads(payloadsExportFile string) error {
 		"Set",
 		"Case",
 		"Test Result",
+		"Response Body SHA256",
+		"Severity",
+		"CWE",
+		"OWASP",
+		"Tags",
+		"References",
 	}); err != nil {
 		return err
 	}
@@ -41,11 +48,11 @@ func (db *DB) ExportPayloads(payloadsExportFile string) error {
 			return err
 		}
 
-		if isFalsePositiveTest(blockedTest.Set) {
+		if blockedTest.isFalsePositive() {
 			testResult = "failed"
 		}
 
-		err = csvWriter.Write([]string{
+		err = csvWriter.Write(append([]string{
 			ep,
 			"blocked",
 			strconv.Itoa(blockedTest.ResponseStatusCode),
@@ -54,7 +61,8 @@ func (db *DB) ExportPayloads(payloadsExportFile string) error {
 			blockedTest.Set,
 			blockedTest.Case,
 			testResult,
-		})
+			blockedTest.Evidence.bodyHash(),
+		}, blockedTest.Metadata.csvFields()...))
 		if err != nil {
 			return err
 		}
@@ -70,11 +78,11 @@ func (db *DB) ExportPayloads(payloadsExportFile string) error {
 			return err
 		}
 
-		if isFalsePositiveTest(passedTest.Set) {
+		if passedTest.isFalsePositive() {
 			testResult = "passed"
 		}
 
-		err = csvWriter.Write([]string{
+		err = csvWriter.Write(append([]string{
 			ep,
 			"passed",
 			strconv.Itoa(passedTest.ResponseStatusCode),
@@ -83,7 +91,8 @@ func (db *DB) ExportPayloads(payloadsExportFile string) error {
 			passedTest.Set,
 			passedTest.Case,
 			testResult,
-		})
+			passedTest.Evidence.bodyHash(),
+		}, passedTest.Metadata.csvFields()...))
 		if err != nil {
 			return err
 		}
@@ -98,16 +107,25 @@ func (db *DB) ExportPayloads(payloadsExportFile string) error {
 			return err
 		}
 
-		err = csvWriter.Write([]string{
+		checkStatus := "unresolved"
+		if naTest.AppRejected {
+			checkStatus = "app rejected"
+		}
+		if naTest.Tarpitted {
+			checkStatus = "tarpitted"
+		}
+
+		err = csvWriter.Write(append([]string{
 			ep,
-			"unresolved",
+			checkStatus,
 			strconv.Itoa(naTest.ResponseStatusCode),
 			naTest.Placeholder,
 			naTest.Encoder,
 			naTest.Set,
 			naTest.Case,
 			"unknown",
-		})
+			naTest.Evidence.bodyHash(),
+		}, naTest.Metadata.csvFields()...))
 		if err != nil {
 			return err
 		}
@@ -115,3 +133,19 @@ func (db *DB) ExportPayloads(payloadsExportFile string) error {
 
 	return nil
 }
+
+// csvFields returns the metadata columns of the exported payloads.
+func (m Metadata) csvFields() []string {
+	var cwe string
+	if m.CWE != 0 {
+		cwe = "CWE-" + strconv.Itoa(m.CWE)
+	}
+
+	return []string{
+		m.Severity,
+		cwe,
+		m.OWASP,
+		strings.Join(m.Tags, " "),
+		strings.Join(m.References, " "),
+	}
+}
@@ -0,0 +1,137 @@
+package db
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/wallarm/gotestwaf/testcases"
+)
+
+// EmbeddedTestCasesPath is shown as the path of the embedded test cases.
+const EmbeddedTestCasesPath = "<embedded>"
+
+// layer is a directory with test cases.
+type layer struct {
+	fs.FS
+	path string
+}
+
+// layeredFS merges the directories with test cases. The file in the upper
+// layer replaces the file with the same path in the lower layers.
+type layeredFS struct {
+	// layers are ordered from the bottom to the top
+	layers []layer
+}
+
+var (
+	_ fs.FS        = (*layeredFS)(nil)
+	_ fs.ReadDirFS = (*layeredFS)(nil)
+)
+
+// NewTestCasesFS returns the file system with the test cases from the
+// testCasesPath, or the embedded test cases if it is empty, and the test
+// cases from the additional paths on top of them.
+func NewTestCasesFS(testCasesPath string, additionalPaths []string) (fs.FS, error) {
+	l := &layeredFS{}
+
+	if testCasesPath == "" {
+		l.layers = append(l.layers, layer{FS: testcases.FS, path: EmbeddedTestCasesPath})
+	} else {
+		additionalPaths = append([]string{testCasesPath}, additionalPaths...)
+	}
+
+	for _, path := range additionalPaths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't open test cases path")
+		}
+
+		if !info.IsDir() {
+			return nil, errors.Errorf("test cases path %s is not a directory", path)
+		}
+
+		l.layers = append(l.layers, layer{FS: os.DirFS(path), path: path})
+	}
+
+	return l, nil
+}
+
+// Open opens the file from the top layer containing it.
+func (l *layeredFS) Open(name string) (fs.File, error) {
+	for i := len(l.layers) - 1; i >= 0; i-- {
+		f, err := l.layers[i].Open(name)
+		if err == nil {
+			return f, nil
+		}
+
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDir merges the entries of the directory in all layers.
+func (l *layeredFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries := make(map[string]fs.DirEntry)
+	found := false
+
+	for _, layer := range l.layers {
+		layerEntries, err := fs.ReadDir(layer, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		found = true
+
+		for _, e := range layerEntries {
+			entries[e.Name()] = e
+		}
+	}
+
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	result := make([]fs.DirEntry, 0, len(entries))
+	for _, e := range entries {
+		result = append(result, e)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Name() < result[j].Name() })
+
+	return result, nil
+}
+
+// path returns the path of the file in the top layer containing it.
+func (l *layeredFS) path(name string) string {
+	for i := len(l.layers) - 1; i >= 0; i-- {
+		if _, err := fs.Stat(l.layers[i], name); err == nil {
+			if l.layers[i].path == EmbeddedTestCasesPath {
+				return name
+			}
+
+			return filepath.Join(l.layers[i].path, filepath.FromSlash(name))
+		}
+	}
+
+	return name
+}
+
+// filePath returns the path of the file in the file system with the test
+// cases to show it in the messages.
+func filePath(fsys fs.FS, name string) string {
+	if l, ok := fsys.(*layeredFS); ok {
+		return l.path(name)
+	}
+
+	return name
+}
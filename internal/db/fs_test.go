@@ -0,0 +1,75 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wallarm/gotestwaf/internal/config"
+)
+
+func TestLoadLayeredTestCases(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		// Replaces the embedded test case
+		"owasp/xss-scripting.yml": `
+payload:
+  - <custom>
+encoder:
+  - Plain
+placeholder:
+  - URLParam
+type: XSS
+`,
+		"custom/sqli.yml": `
+payload:
+  - 1 OR 1=1--
+encoder:
+  - Plain
+placeholder:
+  - URLParam
+type: SQL Injection
+`,
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	embedded, err := LoadTestCases(&config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	layered, err := LoadTestCases(&config.Config{AddTestCasesPaths: []string{dir}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(layered) != len(embedded)+1 {
+		t.Fatalf("got %d test cases, want %d", len(layered), len(embedded)+1)
+	}
+
+	found := make(map[string]*Case)
+	for _, tc := range layered {
+		found[tc.Set+"/"+tc.Name] = tc
+	}
+
+	if xss := found["owasp/xss-scripting"]; xss == nil || len(xss.Payloads) != 1 || xss.Payloads[0] != "<custom>" {
+		t.Errorf("embedded test case isn't replaced: %+v", xss)
+	}
+	if found["custom/sqli"] == nil {
+		t.Errorf("added test case isn't loaded")
+	}
+
+	if _, err = LoadTestCases(&config.Config{AddTestCasesPaths: []string{filepath.Join(dir, "missing")}}); err == nil {
+		t.Errorf("no error for the missing test cases path")
+	}
+}
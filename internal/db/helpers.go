@@ -39,14 +39,40 @@ func CalculatePercentage[A Number, B Number](first A, second B) float64 {
 	return Round(result)
 }
 
+// isFalsePositiveTest checks if the tests of the set are benign by the set
+// name. It is used for the test cases without the expected outcome.
 func isFalsePositiveTest(setName string) bool {
 	return strings.Contains(setName, "false")
 }
 
+// isApiTest checks if the tests of the set have the API category by the set
+// name. It is used for the test cases without the category.
 func isApiTest(setName string) bool {
 	return strings.Contains(setName, "api")
 }
 
+// isFalsePositive checks if the test is expected to pass through the WAF.
+func (i *Info) isFalsePositive() bool {
+	if i.Expect != "" {
+		return i.Expect == ExpectPass
+	}
+
+	return isFalsePositiveTest(i.Set)
+}
+
+// category returns the category of the test, CategoryAPI or CategoryApp.
+func (i *Info) category() string {
+	if i.Category != "" {
+		return i.Category
+	}
+
+	if isApiTest(i.Set) {
+		return CategoryAPI
+	}
+
+	return CategoryApp
+}
+
 func mapToString(m map[any]any) string {
 	for k := range m {
 		return k.(string)
@@ -0,0 +1,402 @@
+package db
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	yamlv2 "gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
+
+	"github.com/wallarm/gotestwaf/internal/payload/encoder"
+	"github.com/wallarm/gotestwaf/internal/payload/placeholder"
+	"github.com/wallarm/gotestwaf/internal/payload/template"
+)
+
+const (
+	payloadKey      = "payload"
+	payloadFilesKey = "payload_files"
+	encoderKey      = "encoder"
+	placeholderKey  = "placeholder"
+	typeKey         = "type"
+	templateKey     = "template"
+	expectKey       = "expect"
+	categoryKey     = "category"
+
+	severityKey   = "severity"
+	cweKey        = "cwe"
+	owaspKey      = "owasp"
+	tagsKey       = "tags"
+	referencesKey = "references"
+)
+
+var yamlErrorLineRegex = regexp.MustCompile(`^yaml: line (\d+): `)
+
+// Diagnostic is a problem found in a test case file.
+type Diagnostic struct {
+	File string
+	// Line is zero if the problem concerns the whole file
+	Line    int
+	Message string
+}
+
+func (d *Diagnostic) String() string {
+	if d.Line == 0 {
+		return fmt.Sprintf("%s: %s", d.File, d.Message)
+	}
+
+	return fmt.Sprintf("%s:%d: %s", d.File, d.Line, d.Message)
+}
+
+// LintTestCases checks all test case files in the file system with the test
+// cases. It validates the directory layout, the keys of the files, the
+// encoder and placeholder names, the placeholder configs and the metadata,
+// and looks for empty lists and duplicates. The returned diagnostics are
+// sorted by file and line.
+func LintTestCases(fsys fs.FS) ([]*Diagnostic, error) {
+	var diagnostics []*Diagnostic
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		fileExt := path.Ext(name)
+		if d.IsDir() || (fileExt != ".yml" && fileExt != ".yaml") {
+			return nil
+		}
+
+		l := &linter{file: filePath(fsys, name), fsys: fsys, name: name}
+
+		if parts := strings.Split(name, "/"); len(parts) != 2 {
+			l.report(nil, "test case file must be placed in <test cases path>/<test set>/<test case>%s", fileExt)
+		}
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		l.lint(data)
+
+		diagnostics = append(diagnostics, l.diagnostics...)
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't read test cases")
+	}
+
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		if diagnostics[i].File != diagnostics[j].File {
+			return diagnostics[i].File < diagnostics[j].File
+		}
+
+		return diagnostics[i].Line < diagnostics[j].Line
+	})
+
+	return diagnostics, nil
+}
+
+// linter collects the problems found in a test case file.
+type linter struct {
+	file string
+	// fsys and name are the file system with the test cases and the name
+	// of the file in it
+	fsys        fs.FS
+	name        string
+	diagnostics []*Diagnostic
+}
+
+func (l *linter) report(node *yaml.Node, format string, args ...any) {
+	d := &Diagnostic{
+		File:    l.file,
+		Message: fmt.Sprintf(format, args...),
+	}
+
+	if node != nil {
+		d.Line = node.Line
+	}
+
+	l.diagnostics = append(l.diagnostics, d)
+}
+
+func (l *linter) lint(data []byte) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		d := &Diagnostic{File: l.file, Message: err.Error()}
+
+		if m := yamlErrorLineRegex.FindStringSubmatch(d.Message); m != nil {
+			d.Line, _ = strconv.Atoi(m[1])
+			d.Message = strings.TrimPrefix(d.Message, m[0])
+		}
+
+		l.diagnostics = append(l.diagnostics, d)
+
+		return
+	}
+
+	if len(doc.Content) == 0 {
+		l.report(nil, "empty test case file")
+		return
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		l.report(root, "test case must be a mapping with %s, %s, %s and %s keys",
+			payloadKey, encoderKey, placeholderKey, typeKey)
+		return
+	}
+
+	values := make(map[string]*yaml.Node)
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+
+		switch key.Value {
+		case payloadKey, payloadFilesKey, encoderKey, placeholderKey, typeKey, templateKey,
+			expectKey, categoryKey, severityKey, cweKey, owaspKey, tagsKey, referencesKey:
+			if _, ok := values[key.Value]; ok {
+				l.report(key, "duplicate key %q", key.Value)
+			}
+			values[key.Value] = value
+
+		default:
+			l.report(key, "unknown key %q", key.Value)
+		}
+	}
+
+	l.lintPayloads(root, values[payloadKey], values[payloadFilesKey])
+	l.lintEncoders(root, values[encoderKey])
+	l.lintPlaceholders(root, values[placeholderKey])
+
+	l.lintTemplates(values[templateKey], values[payloadKey])
+	l.lintExpectation(values)
+	l.lintMetadata(values)
+}
+
+func (l *linter) lintTemplates(node *yaml.Node, payloads *yaml.Node) {
+	if node == nil {
+		return
+	}
+
+	var enabled bool
+	if err := node.Decode(&enabled); err != nil {
+		l.report(node, "%s must be a boolean", templateKey)
+		return
+	}
+
+	if !enabled || payloads == nil || payloads.Kind != yaml.SequenceNode {
+		return
+	}
+
+	for _, item := range payloads.Content {
+		if item.Kind != yaml.ScalarNode {
+			continue
+		}
+
+		if _, err := template.Parse(item.Value); err != nil {
+			l.report(item, "%s", err)
+		}
+	}
+}
+
+func (l *linter) lintExpectation(values map[string]*yaml.Node) {
+	for key, allowed := range map[string][]string{
+		expectKey:   {ExpectBlock, ExpectPass},
+		categoryKey: {CategoryAPI, CategoryApp},
+	} {
+		node, ok := values[key]
+		if !ok {
+			continue
+		}
+
+		if node.Kind != yaml.ScalarNode || !slices.Contains(allowed, strings.ToLower(node.Value)) {
+			l.report(node, "%s must be one of: %s", key, strings.Join(allowed, ", "))
+		}
+	}
+}
+
+func (l *linter) lintMetadata(values map[string]*yaml.Node) {
+	for _, key := range []string{typeKey, severityKey, owaspKey} {
+		if node, ok := values[key]; ok && node.Kind != yaml.ScalarNode {
+			l.report(node, "%s must be a string", key)
+		}
+	}
+
+	if node, ok := values[severityKey]; ok && node.Kind == yaml.ScalarNode {
+		if SeverityRank(strings.ToLower(node.Value)) == 0 {
+			l.report(node, "unknown severity %q, expected one of: %s", node.Value, strings.Join(severities, ", "))
+		}
+	}
+
+	if node, ok := values[cweKey]; ok {
+		if cwe, err := strconv.Atoi(node.Value); node.Kind != yaml.ScalarNode || err != nil || cwe < 0 {
+			l.report(node, "%s must be a CWE number", cweKey)
+		}
+	}
+
+	for _, key := range []string{tagsKey, referencesKey} {
+		node, ok := values[key]
+		if !ok {
+			continue
+		}
+
+		if node.Kind != yaml.SequenceNode {
+			l.report(node, "%s must be a list", key)
+			continue
+		}
+
+		l.scalars(node.Content, key)
+	}
+}
+
+// list checks that the node is a non-empty sequence and returns its items.
+func (l *linter) list(root *yaml.Node, node *yaml.Node, key string) []*yaml.Node {
+	if node == nil {
+		l.report(root, "missing %s list", key)
+		return nil
+	}
+
+	if node.Kind != yaml.SequenceNode {
+		l.report(node, "%s must be a list", key)
+		return nil
+	}
+
+	if len(node.Content) == 0 {
+		l.report(node, "empty %s list", key)
+		return nil
+	}
+
+	return node.Content
+}
+
+// scalars returns the string items of the list, non-string items and
+// duplicates are reported.
+func (l *linter) scalars(items []*yaml.Node, key string) []*yaml.Node {
+	var result []*yaml.Node
+	seen := make(map[string]*yaml.Node)
+
+	for _, item := range items {
+		if item.Kind != yaml.ScalarNode {
+			l.report(item, "%s must be a string", key)
+			continue
+		}
+
+		if first, ok := seen[item.Value]; ok {
+			l.report(item, "duplicate %s %q, first defined at line %d", key, item.Value, first.Line)
+			continue
+		}
+		seen[item.Value] = item
+
+		result = append(result, item)
+	}
+
+	return result
+}
+
+func (l *linter) lintPayloads(root *yaml.Node, node *yaml.Node, filesNode *yaml.Node) {
+	// The payloads may be loaded from the files only
+	if node != nil || filesNode == nil {
+		items := l.list(root, node, payloadKey)
+		l.scalars(items, payloadKey)
+	}
+
+	if filesNode == nil {
+		return
+	}
+
+	if filesNode.Kind != yaml.SequenceNode {
+		l.report(filesNode, "%s must be a list", payloadFilesKey)
+		return
+	}
+
+	for _, item := range filesNode.Content {
+		var f PayloadFile
+		if err := item.Decode(&f); err != nil || f.Path == "" {
+			l.report(item, "payload file must be a path or a mapping with the path")
+			continue
+		}
+
+		payloads, err := f.load(l.fsys, l.name)
+		if err != nil {
+			l.report(item, "%s", err)
+			continue
+		}
+
+		if len(payloads) == 0 {
+			l.report(item, "no payloads in payload file %s", f.Path)
+		}
+	}
+}
+
+func (l *linter) lintEncoders(root *yaml.Node, node *yaml.Node) {
+	items := l.list(root, node, encoderKey)
+
+	for _, item := range l.scalars(items, encoderKey) {
+		if _, ok := encoder.Encoders[item.Value]; !ok {
+			l.report(item, "unknown encoder %q", item.Value)
+		}
+	}
+}
+
+func (l *linter) lintPlaceholders(root *yaml.Node, node *yaml.Node) {
+	items := l.list(root, node, placeholderKey)
+
+	var names []*yaml.Node
+
+	for _, item := range items {
+		switch item.Kind {
+		case yaml.ScalarNode:
+			names = append(names, item)
+
+		case yaml.MappingNode:
+			if len(item.Content) != 2 {
+				l.report(item, "placeholder with config must be a mapping with a single key")
+				continue
+			}
+
+			l.lintPlaceholderConfig(item.Content[0], item.Content[1])
+
+		default:
+			l.report(item, "placeholder must be a name or a mapping of the name to the config")
+		}
+	}
+
+	for _, name := range l.scalars(names, placeholderKey) {
+		if _, ok := placeholder.Placeholders[name.Value]; !ok {
+			l.report(name, "unknown placeholder %q", name.Value)
+		}
+	}
+}
+
+func (l *linter) lintPlaceholderConfig(name *yaml.Node, config *yaml.Node) {
+	if _, ok := placeholder.Placeholders[name.Value]; !ok {
+		l.report(name, "unknown placeholder %q", name.Value)
+		return
+	}
+
+	// The placeholder configs are parsed from the types produced by yaml.v2,
+	// as in LoadTestCases
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		l.report(config, "couldn't parse config of %s placeholder: %s", name.Value, err)
+		return
+	}
+
+	var conf any
+	if err = yamlv2.Unmarshal(data, &conf); err != nil {
+		l.report(config, "couldn't parse config of %s placeholder: %s", name.Value, err)
+		return
+	}
+
+	if _, err = placeholder.GetPlaceholderConfig(name.Value, conf); err != nil {
+		l.report(config, "%s", err)
+	}
+}
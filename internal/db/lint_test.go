@@ -0,0 +1,148 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wallarm/gotestwaf/testcases"
+)
+
+func TestLintTestCases(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"owasp/valid.yml": `
+payload:
+  - <script>
+encoder:
+  - Plain
+placeholder:
+  - URLParam
+  - RawRequest:
+      method: POST
+      body: "{{payload}}"
+type: XSS
+expect: block
+category: App
+severity: High
+cwe: 79
+owasp: A03:2021
+tags: [xss, script]
+references:
+  - https://owasp.org/www-community/attacks/xss/
+`,
+		"owasp/invalid.yml": `
+payloads:
+  - <script>
+encoder:
+  - Plian
+  - Plain
+  - Plain
+placeholder:
+  - URLParm
+  - RawRequest:
+      path: /
+type: XSS
+severity: urgent
+cwe: CWE-79
+tags: [xss, xss]
+expect: allow
+category: [web]
+`,
+		"owasp/empty.yaml": `
+payload: []
+encoder:
+  - Plain
+placeholder:
+  - URLParam
+  - URLParam
+`,
+		"misplaced.yml": `
+payload:
+  - <script>
+encoder:
+  - Plain
+placeholder:
+  - URLParam
+`,
+		"owasp/broken.yml": "payload:\n  - a\n b: c\n",
+		"owasp/files.yml": `
+payload_files:
+  - missing.txt
+  - path: empty.txt
+encoder:
+  - Plain
+placeholder:
+  - URLParam
+`,
+		"owasp/empty.txt": "# no payloads\n",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fsys, err := NewTestCasesFS(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diagnostics, err := LintTestCases(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, d := range diagnostics {
+		got = append(got, strings.TrimPrefix(d.String(), dir+string(os.PathSeparator)))
+	}
+
+	want := []string{
+		"misplaced.yml: test case file must be placed in <test cases path>/<test set>/<test case>.yml",
+		"owasp/broken.yml:2: did not find expected key",
+		"owasp/empty.yaml:2: empty payload list",
+		"owasp/empty.yaml:7: duplicate placeholder \"URLParam\", first defined at line 6",
+		"owasp/files.yml:3: couldn't open payload file: open ",
+		"owasp/files.yml:4: no payloads in payload file empty.txt",
+		"owasp/invalid.yml:2: unknown key \"payloads\"",
+		"owasp/invalid.yml:2: missing payload list",
+		"owasp/invalid.yml:5: unknown encoder \"Plian\"",
+		"owasp/invalid.yml:7: duplicate encoder \"Plain\", first defined at line 6",
+		"owasp/invalid.yml:9: unknown placeholder \"URLParm\"",
+		"owasp/invalid.yml:11: bad config for RawRequest placeholder",
+		"owasp/invalid.yml:13: unknown severity \"urgent\"",
+		"owasp/invalid.yml:14: cwe must be a CWE number",
+		"owasp/invalid.yml:15: duplicate tags \"xss\", first defined at line 15",
+		"owasp/invalid.yml:16: expect must be one of: block, pass",
+		"owasp/invalid.yml:17: category must be one of: api, app",
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Errorf("got %q, want %q", got[i], want[i])
+		}
+	}
+}
+
+func TestLintEmbeddedTestCases(t *testing.T) {
+	diagnostics, err := LintTestCases(testcases.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, d := range diagnostics {
+		t.Errorf("embedded test cases: %s", d)
+	}
+}
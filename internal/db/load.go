@@ -1,8 +1,8 @@
 package db
 
 import (
-	"os"
-	"path/filepath"
+	"io/fs"
+	"path"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -10,34 +10,61 @@ import (
 
 	"github.com/wallarm/gotestwaf/internal/config"
 	"github.com/wallarm/gotestwaf/internal/payload/placeholder"
+	"github.com/wallarm/gotestwaf/internal/payload/template"
 )
 
-func LoadTestCases(cfg *config.Config) (testCases []*Case, err error) {
+// LoadTestCases loads the test cases from the test cases path, or the
+// embedded test cases if the path is empty, and the additional test cases
+// paths layered on top of it.
+func LoadTestCases(cfg *config.Config) ([]*Case, error) {
+	fsys, err := NewTestCasesFS(cfg.TestCasesPath, cfg.AddTestCasesPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	return LoadTestCasesFS(cfg, fsys)
+}
+
+// LoadTestCasesFS loads the test cases from the file system. The test case
+// files are placed as <test set>/<test case>.yml, the files in the
+// subdirectories are loaded as the test cases of the parent directory.
+func LoadTestCasesFS(cfg *config.Config, fsys fs.FS) (testCases []*Case, err error) {
 	var files []string
 
-	if cfg.TestCasesPath == "" {
-		return nil, errors.New("empty test cases path")
+	selectors, err := NewSelectors(cfg.Include, cfg.Exclude)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't parse test selectors")
 	}
 
-	if err = filepath.Walk(cfg.TestCasesPath, func(path string, info os.FileInfo, err error) error {
-		files = append(files, path)
+	if err = fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() {
+			files = append(files, path)
+		}
+
 		return nil
 	}); err != nil {
-		return nil, err
+		return nil, errors.Wrap(err, "couldn't read test cases")
 	}
 
-	for _, testCaseFile := range files {
-		fileExt := filepath.Ext(testCaseFile)
+	for _, file := range files {
+		fileExt := path.Ext(file)
 		if fileExt != ".yml" && fileExt != ".yaml" {
 			continue
 		}
 
 		// Ignore subdirectories, process as .../<testSetName>/<testCaseName>/<case>.yml
-		parts := strings.Split(testCaseFile, string(os.PathSeparator))
-		parts = parts[len(parts)-3:]
+		parts := strings.Split(file, "/")
+		if len(parts) < 2 {
+			continue
+		}
+		parts = parts[len(parts)-2:]
 
-		testSetName := parts[1]
-		testCaseName := strings.TrimSuffix(parts[2], fileExt)
+		testSetName := parts[0]
+		testCaseName := strings.TrimSuffix(parts[1], fileExt)
 
 		if cfg.TestSet != "" && testSetName != cfg.TestSet {
 			continue
@@ -47,7 +74,9 @@ func LoadTestCases(cfg *config.Config) (testCases []*Case, err error) {
 			continue
 		}
 
-		yamlFile, err := os.ReadFile(testCaseFile)
+		testCaseFile := filePath(fsys, file)
+
+		yamlFile, err := fs.ReadFile(fsys, file)
 		if err != nil {
 			return nil, err
 		}
@@ -58,6 +87,34 @@ func LoadTestCases(cfg *config.Config) (testCases []*Case, err error) {
 			return nil, err
 		}
 
+		t.Severity = strings.ToLower(t.Severity)
+		if t.Severity != "" && SeverityRank(t.Severity) == 0 {
+			return nil, errors.Errorf("couldn't parse config: unknown severity %q in %s, expected one of: %s",
+				t.Severity, testCaseFile, strings.Join(severities, ", "))
+		}
+
+		isTruePositive, category, err := t.expectation(testSetName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't parse config: bad test case %s", testCaseFile)
+		}
+
+		for i := range t.PayloadFiles {
+			payloads, loadErr := t.PayloadFiles[i].load(fsys, file)
+			if loadErr != nil {
+				return nil, loadErr
+			}
+
+			t.Payloads = append(t.Payloads, payloads...)
+		}
+
+		if t.Template {
+			for _, payload := range t.Payloads {
+				if _, parseErr := template.Parse(payload); parseErr != nil {
+					return nil, errors.Wrapf(parseErr, "couldn't parse config: bad payload in %s", testCaseFile)
+				}
+			}
+		}
+
 		var placeholders []*Placeholder
 		for _, ph := range t.Placeholders {
 			switch typedPh := ph.(type) {
@@ -86,13 +143,16 @@ func LoadTestCases(cfg *config.Config) (testCases []*Case, err error) {
 			Encoders:       t.Encoders,
 			Placeholders:   placeholders,
 			Type:           t.Type,
+			Template:       t.Template,
 			Set:            testSetName,
 			Name:           testCaseName,
-			IsTruePositive: true, // test case is true positive
+			IsTruePositive: isTruePositive,
+			Category:       category,
+			Metadata:       t.Metadata,
 		}
 
-		if strings.Contains(testSetName, "false") {
-			testCase.IsTruePositive = false // test case is false positive
+		if !selectors.apply(testCase) {
+			continue
 		}
 
 		testCases = append(testCases, testCase)
@@ -104,3 +164,33 @@ func LoadTestCases(cfg *config.Config) (testCases []*Case, err error) {
 
 	return testCases, nil
 }
+
+// expectation returns the expected outcome and the category of the test case.
+// If they aren't set, they are inferred from the test set name: the test set
+// with "false" in the name contains benign requests, and the test set with
+// "api" in the name has the API category.
+func (t *yamlConfig) expectation(testSetName string) (isTruePositive bool, category string, err error) {
+	switch strings.ToLower(t.Expect) {
+	case ExpectBlock:
+		isTruePositive = true
+	case ExpectPass:
+		isTruePositive = false
+	case "":
+		isTruePositive = !isFalsePositiveTest(testSetName)
+	default:
+		return false, "", errors.Errorf("unknown expect %q, expected %s or %s", t.Expect, ExpectBlock, ExpectPass)
+	}
+
+	switch category = strings.ToLower(t.Category); category {
+	case CategoryAPI, CategoryApp:
+	case "":
+		category = CategoryApp
+		if isApiTest(testSetName) {
+			category = CategoryAPI
+		}
+	default:
+		return false, "", errors.Errorf("unknown category %q, expected %s or %s", t.Category, CategoryAPI, CategoryApp)
+	}
+
+	return isTruePositive, category, nil
+}
@@ -2,6 +2,10 @@ package db
 
 import (
 	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/wallarm/gotestwaf/internal/payload/placeholder"
 
@@ -17,28 +21,197 @@ type Info struct {
 	ResponseStatusCode int
 	AdditionalInfo     []string
 	Type               string
+
+	// Confidence of the block decision made by similarity to the calibration
+	// responses, 0 if the decision was made by the status codes or regexes.
+	Confidence float64
+
+	// AppRejected is true if the application rejected the request shape in
+	// the same way as for the benign payload.
+	AppRejected bool
+
+	// BlockedBy contains names of the identified WAFs whose signatures
+	// matched the blocked response.
+	BlockedBy []string
+
+	// MatchedRule is the name of the response rule which classified the
+	// response.
+	MatchedRule string
+
+	// Latency is the response time of the request.
+	Latency time.Duration
+	// Tarpitted is true if the response was significantly delayed compared
+	// to the benign requests or the request timed out.
+	Tarpitted bool
+
+	// Evidence contains the response data, it is saved only if requested.
+	Evidence *Evidence
+
+	// PayloadTemplate is the template the payload was rendered from, it is
+	// empty if the test case doesn't use templates.
+	PayloadTemplate string
+
+	// Expect is the expected outcome of the test, ExpectBlock or ExpectPass.
+	// If it is empty, the outcome is inferred from the test set name.
+	Expect string
+	// Category is the category of the test, CategoryAPI or CategoryApp. If
+	// it is empty, the category is inferred from the test set name.
+	Category string
+
+	Metadata
+}
+
+// Evidence is the response data saved to check the test result without
+// sending the payload again.
+type Evidence struct {
+	Headers http.Header `json:"headers,omitempty"`
+	// Body is the initial part of the response body
+	Body       string `json:"body,omitempty"`
+	BodySize   int    `json:"body_size"`
+	BodySHA256 string `json:"body_sha256"`
+}
+
+// NewEvidence creates Evidence with the response headers and at most
+// maxBodySize bytes of the body. The hash is calculated over the whole body.
+func NewEvidence(headers http.Header, body []byte, maxBodySize int) *Evidence {
+	hash := sha256.Sum256(body)
+
+	excerpt := body
+	if maxBodySize >= 0 && len(excerpt) > maxBodySize {
+		excerpt = excerpt[:maxBodySize]
+	}
+
+	return &Evidence{
+		Headers:    headers.Clone(),
+		Body:       strings.ToValidUTF8(string(excerpt), "\uFFFD"),
+		BodySize:   len(body),
+		BodySHA256: hex.EncodeToString(hash[:]),
+	}
+}
+
+// bodyHash returns the hash of the response body or an empty string if the
+// evidence wasn't saved.
+func (e *Evidence) bodyHash() string {
+	if e == nil {
+		return ""
+	}
+
+	return e.BodySHA256
+}
+
+// Expected outcomes of the tests: malicious requests must be blocked by the
+// WAF, benign requests must pass.
+const (
+	ExpectBlock = "block"
+	ExpectPass  = "pass"
+)
+
+// Categories of the tests used to calculate the ApiSec and AppSec scores.
+const (
+	CategoryAPI = "api"
+	CategoryApp = "app"
+)
+
+// Severity levels of the test cases from the most to the least severe.
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityMedium   = "medium"
+	SeverityLow      = "low"
+	SeverityInfo     = "info"
+)
+
+var severities = []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityInfo}
+
+// SeverityRank returns the rank of the severity, the higher rank is the more
+// severe. Unknown and empty severities have rank 0.
+func SeverityRank(severity string) int {
+	for i, s := range severities {
+		if s == severity {
+			return len(severities) - i
+		}
+	}
+
+	return 0
+}
+
+// Metadata is the optional information about the risk of the test case used
+// to sort the results and map them to the risk registers.
+type Metadata struct {
+	Severity   string   `json:"severity,omitempty" yaml:"severity" validate:"omitempty,oneof=critical high medium low info"`
+	CWE        int      `json:"cwe,omitempty" yaml:"cwe" validate:"min=0"`
+	OWASP      string   `json:"owasp,omitempty" yaml:"owasp" validate:"omitempty,printascii,max=64"`
+	Tags       []string `json:"tags,omitempty" yaml:"tags" validate:"omitempty,max=64,dive,required,printascii,max=64"`
+	References []string `json:"references,omitempty" yaml:"references" validate:"omitempty,max=64,dive,url,max=1024"`
 }
 
 type yamlConfig struct {
-	Payloads     []string `yaml:"payload"`
-	Encoders     []string `yaml:"encoder"`
-	Placeholders []any    `yaml:"placeholder"` // array of string or map[string]any
-	Type         string   `default:"unknown" yaml:"type"`
+	Payloads     []string      `yaml:"payload"`
+	PayloadFiles []PayloadFile `yaml:"payload_files"`
+	Encoders     []string      `yaml:"encoder"`
+	Placeholders []any         `yaml:"placeholder"` // array of string or map[string]any
+	Type         string        `default:"unknown" yaml:"type"`
+	Template     bool          `yaml:"template"`
+	Expect       string        `yaml:"expect"`
+	Category     string        `yaml:"category"`
+
+	Metadata `yaml:",inline"`
 }
 
 type Case struct {
+	// Payloads contains the inline payloads followed by the payloads from
+	// the payload files, so the contents of the files are included in the
+	// hash
 	Payloads     []string
 	Encoders     []string
 	Placeholders []*Placeholder
 	Type         string
 
+	// Template is true if the payloads are templates rendered before sending
+	Template bool
+
+	// Metadata doesn't affect the requests and isn't included in the hash
+	Metadata
+
+	// selectors select a part of the encoder and placeholder combinations,
+	// nil if all combinations are selected
+	selectors *Selectors
+
 	Set            string
 	Name           string
 	IsTruePositive bool
+
+	// Category is CategoryAPI or CategoryApp, it doesn't affect the requests
+	// and isn't included in the hash
+	Category string
 }
 
 var _ helpers.Hash = (*Case)(nil)
 
+// Selected checks if the test with the encoder and the placeholder is
+// selected by the --include and --exclude options.
+func (p *Case) Selected(encoder, placeholder string) bool {
+	return p.selectors.Match(p, encoder, placeholder)
+}
+
+// NumberOfTests returns the number of the selected tests of the case.
+func (p *Case) NumberOfTests() int {
+	if p.selectors == nil {
+		return len(p.Payloads) * len(p.Encoders) * len(p.Placeholders)
+	}
+
+	var n int
+	for _, e := range p.Encoders {
+		for _, ph := range p.Placeholders {
+			if p.Selected(e, ph.Name) {
+				n += len(p.Payloads)
+			}
+		}
+	}
+
+	return n
+}
+
 func (p *Case) Hash() []byte {
 	sha256sum := sha256.New()
 
@@ -56,6 +229,22 @@ func (p *Case) Hash() []byte {
 		}
 	}
 
+	// Only a part of the combinations is selected
+	if p.selectors != nil {
+		for _, e := range p.Encoders {
+			for _, ph := range p.Placeholders {
+				if p.Selected(e, ph.Name) {
+					sha256sum.Write([]byte(e))
+					sha256sum.Write([]byte(ph.Name))
+				}
+			}
+		}
+	}
+
+	if p.Template {
+		sha256sum.Write([]byte("template"))
+	}
+
 	sha256sum.Write([]byte(p.Type))
 	sha256sum.Write([]byte(p.Set))
 	sha256sum.Write([]byte(p.Name))
@@ -0,0 +1,33 @@
+package db
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestNewEvidence(t *testing.T) {
+	headers := http.Header{"Server": []string{"nginx"}}
+	body := []byte("<html>Access denied</html>")
+
+	e := NewEvidence(headers, body, 12)
+
+	if e.Body != "<html>Access" {
+		t.Errorf("got body excerpt %q", e.Body)
+	}
+	if e.BodySize != len(body) {
+		t.Errorf("got body size %d, want %d", e.BodySize, len(body))
+	}
+	if e.BodySHA256 != NewEvidence(nil, body, 0).BodySHA256 {
+		t.Errorf("body hash depends on the excerpt size")
+	}
+
+	headers.Set("Server", "apache")
+	if e.Headers.Get("Server") != "nginx" {
+		t.Errorf("evidence headers are not copied")
+	}
+
+	var noEvidence *Evidence
+	if noEvidence.bodyHash() != "" {
+		t.Errorf("got body hash without evidence")
+	}
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// maxPayloadLineSize is the maximum size of a payload in a payload file.
+const maxPayloadLineSize = 16 * 1024 * 1024
+
+// PayloadFile is a wordlist file with a payload per line. Blank lines and
+// lines starting with "#" are skipped unless KeepBlankLines or KeepComments
+// is set. The relative path is resolved against the directory of the test
+// case file in the test cases path.
+type PayloadFile struct {
+	Path           string `yaml:"path"`
+	KeepComments   bool   `yaml:"keep_comments"`
+	KeepBlankLines bool   `yaml:"keep_blank_lines"`
+}
+
+// UnmarshalYAML allows to set the payload file by the path only.
+func (f *PayloadFile) UnmarshalYAML(unmarshal func(any) error) error {
+	var path string
+	if err := unmarshal(&path); err == nil {
+		f.Path = path
+		return nil
+	}
+
+	type plain PayloadFile
+	return unmarshal((*plain)(f))
+}
+
+// open opens the payload file referenced by the test case file in the file
+// system with the test cases. The relative path must not leave the file
+// system.
+func (f *PayloadFile) open(fsys fs.FS, testCaseFile string) (fs.File, string, error) {
+	if filepath.IsAbs(f.Path) {
+		file, err := os.Open(f.Path)
+		return file, f.Path, err
+	}
+
+	name := path.Join(path.Dir(testCaseFile), filepath.ToSlash(f.Path))
+	if !fs.ValidPath(name) {
+		return nil, "", errors.Errorf("payload file %s is outside the test cases path", f.Path)
+	}
+
+	file, err := fsys.Open(name)
+
+	return file, filePath(fsys, name), err
+}
+
+// load reads the payloads from the file referenced by the test case file.
+func (f *PayloadFile) load(fsys fs.FS, testCaseFile string) ([]string, error) {
+	file, path, err := f.open(fsys, testCaseFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't open payload file")
+	}
+	defer file.Close()
+
+	var payloads []string
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, maxPayloadLineSize)
+
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+
+		if !f.KeepBlankLines && strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if !f.KeepComments && strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		payloads = append(payloads, line)
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "couldn't read payload file %s", path)
+	}
+
+	return payloads, nil
+}
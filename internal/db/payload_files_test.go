@@ -0,0 +1,87 @@
+package db
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wallarm/gotestwaf/internal/config"
+)
+
+func TestLoadPayloadFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"owasp/xss.yml": `
+payload:
+  - <script>
+payload_files:
+  - xss.txt
+  - path: ../wordlists/comments.txt
+    keep_comments: true
+encoder:
+  - Plain
+placeholder:
+  - URLParam
+type: XSS
+`,
+		"owasp/xss.txt":          "# XSS payloads\r\n<img src=x>\r\n\r\n<svg onload=alert(1)>\r\n",
+		"wordlists/comments.txt": "#<script>\n",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &config.Config{TestCasesPath: dir, TestSet: "owasp"}
+
+	testCases, err := LoadTestCases(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(testCases) != 1 {
+		t.Fatalf("got %d test cases, want 1", len(testCases))
+	}
+
+	want := []string{"<script>", "<img src=x>", "<svg onload=alert(1)>", "#<script>"}
+
+	payloads := testCases[0].Payloads
+	if len(payloads) != len(want) {
+		t.Fatalf("got payloads %q, want %q", payloads, want)
+	}
+	for i := range want {
+		if payloads[i] != want[i] {
+			t.Errorf("got payload %q, want %q", payloads[i], want[i])
+		}
+	}
+
+	hash := testCases[0].Hash()
+
+	err = os.WriteFile(filepath.Join(dir, "owasp/xss.txt"), []byte("<img src=y>\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases, err = LoadTestCases(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(testCases[0].Hash(), hash) {
+		t.Errorf("hash isn't changed after the payload file is changed")
+	}
+
+	if err = os.Remove(filepath.Join(dir, "owasp/xss.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = LoadTestCases(cfg); err == nil {
+		t.Errorf("no error for the missing payload file")
+	}
+}
@@ -0,0 +1,222 @@
+package db
+
+import (
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Keys of the selectors which match the test attributes.
+const (
+	selectorSet         = "set"
+	selectorCase        = "case"
+	selectorTag         = "tag"
+	selectorType        = "type"
+	selectorEncoder     = "encoder"
+	selectorPlaceholder = "placeholder"
+)
+
+var selectorKeys = []string{
+	selectorSet, selectorCase, selectorTag, selectorType, selectorEncoder, selectorPlaceholder,
+}
+
+// selector matches the tests by a glob pattern. The pattern without a key
+// matches "<set>/<case>" if it contains a slash, otherwise it matches the
+// set or the case name.
+type selector struct {
+	key     string
+	pattern string
+}
+
+func parseSelector(s string) (*selector, error) {
+	sel := &selector{pattern: s}
+
+	if key, pattern, ok := strings.Cut(s, "="); ok {
+		sel.key = strings.TrimSpace(key)
+		sel.pattern = pattern
+
+		known := false
+		for _, k := range selectorKeys {
+			if sel.key == k {
+				known = true
+				break
+			}
+		}
+
+		if !known {
+			return nil, errors.Errorf("unknown selector key %q in %q, expected one of: %s",
+				sel.key, s, strings.Join(selectorKeys, ", "))
+		}
+	}
+
+	sel.pattern = strings.ToLower(strings.TrimSpace(sel.pattern))
+	if sel.pattern == "" {
+		return nil, errors.Errorf("empty selector pattern in %q", s)
+	}
+
+	if _, err := path.Match(sel.pattern, ""); err != nil {
+		return nil, errors.Wrapf(err, "bad selector pattern %q", s)
+	}
+
+	return sel, nil
+}
+
+func (s *selector) match(c *Case, encoder, placeholder string) bool {
+	switch s.key {
+	case selectorSet:
+		return s.matchString(c.Set)
+	case selectorCase:
+		return s.matchString(c.Name)
+	case selectorType:
+		return s.matchString(c.Type)
+	case selectorEncoder:
+		return s.matchString(encoder)
+	case selectorPlaceholder:
+		return s.matchString(placeholder)
+	case selectorTag:
+		for _, tag := range c.Tags {
+			if s.matchString(tag) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if strings.Contains(s.pattern, "/") {
+		return s.matchString(c.Set + "/" + c.Name)
+	}
+
+	return s.matchString(c.Set) || s.matchString(c.Name)
+}
+
+func (s *selector) matchString(value string) bool {
+	ok, _ := path.Match(s.pattern, strings.ToLower(value))
+	return ok
+}
+
+// Selectors select the tests by the set, the case, the tags, the attack type,
+// the encoder and the placeholder. The test is selected if it matches any of
+// the include selectors, or there are no include selectors, and doesn't match
+// any of the exclude selectors.
+type Selectors struct {
+	include []*selector
+	exclude []*selector
+}
+
+// NewSelectors parses the include and exclude selectors. The selector has
+// the "[<key>=]<glob>" format, the selector prefixed with "!" is moved to the
+// opposite list, e.g. "--include='!*128kb*'" is the same as
+// "--exclude='*128kb*'". It returns nil if there are no selectors.
+func NewSelectors(include, exclude []string) (*Selectors, error) {
+	s := &Selectors{}
+
+	add := func(list []string, positive, negative *[]*selector) error {
+		for _, item := range list {
+			target := positive
+			if strings.HasPrefix(item, "!") {
+				item = item[1:]
+				target = negative
+			}
+
+			sel, err := parseSelector(item)
+			if err != nil {
+				return err
+			}
+
+			*target = append(*target, sel)
+		}
+
+		return nil
+	}
+
+	if err := add(include, &s.include, &s.exclude); err != nil {
+		return nil, err
+	}
+	if err := add(exclude, &s.exclude, &s.include); err != nil {
+		return nil, err
+	}
+
+	if len(s.include) == 0 && len(s.exclude) == 0 {
+		return nil, nil
+	}
+
+	return s, nil
+}
+
+// Match checks if the test of the case with the encoder and the placeholder
+// is selected. All tests are selected by nil Selectors.
+func (s *Selectors) Match(c *Case, encoder, placeholder string) bool {
+	if s == nil {
+		return true
+	}
+
+	for _, sel := range s.exclude {
+		if sel.match(c, encoder, placeholder) {
+			return false
+		}
+	}
+
+	if len(s.include) == 0 {
+		return true
+	}
+
+	for _, sel := range s.include {
+		if sel.match(c, encoder, placeholder) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// apply removes the encoders and the placeholders of the case which aren't
+// used by any selected test. It returns false if no tests are selected. The
+// selectors are kept in the case if only a part of the remaining
+// combinations is selected.
+func (s *Selectors) apply(c *Case) bool {
+	if s == nil {
+		return true
+	}
+
+	usedEncoders := make(map[string]bool)
+	usedPlaceholders := make(map[*Placeholder]bool)
+	selected := 0
+
+	for _, e := range c.Encoders {
+		for _, ph := range c.Placeholders {
+			if s.Match(c, e, ph.Name) {
+				usedEncoders[e] = true
+				usedPlaceholders[ph] = true
+				selected++
+			}
+		}
+	}
+
+	if selected == 0 {
+		return false
+	}
+
+	var encoders []string
+	for _, e := range c.Encoders {
+		if usedEncoders[e] {
+			encoders = append(encoders, e)
+		}
+	}
+
+	var placeholders []*Placeholder
+	for _, ph := range c.Placeholders {
+		if usedPlaceholders[ph] {
+			placeholders = append(placeholders, ph)
+		}
+	}
+
+	c.Encoders = encoders
+	c.Placeholders = placeholders
+
+	if selected != len(encoders)*len(placeholders) {
+		c.selectors = s
+	}
+
+	return true
+}
@@ -0,0 +1,102 @@
+package db
+
+import (
+	"bytes"
+	"testing"
+)
+
+func newSelectorTestCase(set, name string) *Case {
+	return &Case{
+		Payloads:     []string{"a", "b"},
+		Encoders:     []string{"Plain", "URL"},
+		Placeholders: []*Placeholder{{Name: "URLParam"}, {Name: "Header"}},
+		Type:         "SQL Injection",
+		Set:          set,
+		Name:         name,
+		Metadata:     Metadata{Tags: []string{"sqli", "time-based"}},
+	}
+}
+
+func TestSelectors(t *testing.T) {
+	tests := []struct {
+		include  []string
+		exclude  []string
+		set      string
+		name     string
+		expected int
+	}{
+		{nil, nil, "owasp", "sql-injection", 8},
+		{[]string{"owasp/*"}, nil, "owasp", "sql-injection", 8},
+		{[]string{"owasp/*"}, nil, "community", "sql-injection", 0},
+		{[]string{"sql-*"}, nil, "owasp", "sql-injection", 8},
+		{[]string{"!*128kb*"}, nil, "community", "community-128kb-sqli", 0},
+		{nil, []string{"*128kb*"}, "community", "community-128kb-sqli", 0},
+		{[]string{"placeholder=header"}, nil, "owasp", "sql-injection", 4},
+		{[]string{"placeholder=Header", "encoder=URL"}, nil, "owasp", "sql-injection", 6},
+		{[]string{"tag=sqli"}, []string{"encoder=URL"}, "owasp", "sql-injection", 4},
+		{nil, []string{"!placeholder=Header"}, "owasp", "sql-injection", 4},
+		{[]string{"type=sql*"}, []string{"case=sql*"}, "owasp", "sql-injection", 0},
+		{[]string{"set=owasp", "case=xss"}, nil, "owasp-api", "xss", 8},
+		{[]string{"tag=xss"}, nil, "owasp", "sql-injection", 0},
+	}
+
+	for _, tt := range tests {
+		selectors, err := NewSelectors(tt.include, tt.exclude)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c := newSelectorTestCase(tt.set, tt.name)
+
+		var n int
+		if selectors.apply(c) {
+			n = c.NumberOfTests()
+		}
+
+		if n != tt.expected {
+			t.Errorf("include %v, exclude %v: got %d tests of %s/%s, want %d",
+				tt.include, tt.exclude, n, tt.set, tt.name, tt.expected)
+		}
+	}
+}
+
+func TestSelectorsHash(t *testing.T) {
+	full := newSelectorTestCase("owasp", "sql-injection")
+
+	// The selection of the whole encoders and placeholders doesn't differ
+	// from the case with these encoders and placeholders only
+	selectors, err := NewSelectors([]string{"encoder=Plain"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	selected := newSelectorTestCase("owasp", "sql-injection")
+	selectors.apply(selected)
+
+	plain := newSelectorTestCase("owasp", "sql-injection")
+	plain.Encoders = []string{"Plain"}
+
+	if !bytes.Equal(selected.Hash(), plain.Hash()) {
+		t.Errorf("hash of the selected encoder differs from the hash of the case with the encoder")
+	}
+
+	selectors, err = NewSelectors([]string{"encoder=Plain", "placeholder=Header"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	selected = newSelectorTestCase("owasp", "sql-injection")
+	selectors.apply(selected)
+
+	if bytes.Equal(selected.Hash(), full.Hash()) {
+		t.Errorf("hash of the partially selected case equals the hash of the whole case")
+	}
+}
+
+func TestNewSelectorsErrors(t *testing.T) {
+	for _, s := range []string{"method=GET", "placeholder=", "owasp/[", "!"} {
+		if _, err := NewSelectors([]string{s}, nil); err == nil {
+			t.Errorf("no error for selector %q", s)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"sort"
+	"time"
 )
 
 type Statistics struct {
@@ -15,6 +16,9 @@ type Statistics struct {
 	TruePositiveTests TestsSummary
 	TrueNegativeTests TestsSummary
 
+	// Latency contains the response time distribution for each test set
+	Latency map[string]*LatencyDistribution
+
 	Score struct {
 		ApiSec  Score
 		AppSec  Score
@@ -37,6 +41,31 @@ type TestsSummary struct {
 	ResolvedBlockedRequestsPercentage  float64
 	ResolvedBypassedRequestsPercentage float64
 	FailedRequestsPercentage           float64
+
+	// AppRejectedRequestsNumber is the number of unresolved requests rejected
+	// by the application in the same way as the benign baseline requests
+	AppRejectedRequestsNumber int
+
+	// BlockedByWAF contains the number of blocked requests by the identified
+	// WAF whose signature matched the response
+	BlockedByWAF map[string]int
+
+	// MatchedRules contains the number of tests classified by each response
+	// rule
+	MatchedRules map[string]int
+
+	// TarpittedRequestsNumber is the number of unresolved requests which were
+	// significantly delayed or timed out
+	TarpittedRequestsNumber int
+}
+
+// LatencyDistribution describes the response time of the requests.
+type LatencyDistribution struct {
+	Requests int
+	Min      time.Duration
+	Median   time.Duration
+	P90      time.Duration
+	Max      time.Duration
 }
 
 type SummaryTableRow struct {
@@ -48,6 +77,8 @@ type SummaryTableRow struct {
 	Bypassed   int     `json:"bypassed" validate:"min=0"`
 	Unresolved int     `json:"unresolved" validate:"min=0"`
 	Failed     int     `json:"failed" validate:"min=0"`
+
+	Metadata
 }
 
 type TestDetails struct {
@@ -59,6 +90,18 @@ type TestDetails struct {
 	ResponseStatusCode int
 	AdditionalInfo     []string
 	Type               string
+	Confidence         float64
+	AppRejected        bool
+	BlockedBy          []string
+	MatchedRule        string
+	Latency            time.Duration
+	Tarpitted          bool
+	Evidence           *Evidence
+
+	// Category is CategoryAPI or CategoryApp
+	Category string
+
+	Metadata
 }
 
 type FailedDetails struct {
@@ -69,6 +112,8 @@ type FailedDetails struct {
 	Placeholder string   `json:"placeholder" validate:"required,printascii"`
 	Reason      []string `json:"reason" validate:"omitempty,dive,required"`
 	Type        string   `json:"type" validate:"omitempty"`
+
+	Metadata
 }
 
 type RequestStats struct {
@@ -143,6 +188,15 @@ func (db *DB) GetStatistics(ignoreUnresolved, nonBlockedAsPassed bool) *Statisti
 		}
 	}
 
+	cases := make(map[string]map[string]*Case)
+	for _, t := range db.tests {
+		if cases[t.Set] == nil {
+			cases[t.Set] = make(map[string]*Case)
+		}
+
+		cases[t.Set][t.Name] = t
+	}
+
 	// Sort all test sets by name
 	var sortedTestSets []string
 	for testSet := range db.counters {
@@ -158,9 +212,15 @@ func (db *DB) GetStatistics(ignoreUnresolved, nonBlockedAsPassed bool) *Statisti
 		}
 		sort.Strings(sortedTestCases)
 
-		isFalsePositive := isFalsePositiveTest(testSet)
-
 		for _, testCase := range sortedTestCases {
+			isFalsePositive := isFalsePositiveTest(testSet)
+			var metadata Metadata
+
+			if c, ok := cases[testSet][testCase]; ok {
+				isFalsePositive = !c.IsTruePositive
+				metadata = c.Metadata
+			}
+
 			// Number of requests for all request types for the selected testCase
 			unresolvedRequests := unresolvedRequestsNumber[testSet][testCase]
 			passedRequests := db.counters[testSet][testCase]["passed"]
@@ -188,6 +248,7 @@ func (db *DB) GetStatistics(ignoreUnresolved, nonBlockedAsPassed bool) *Statisti
 				Bypassed:   passedRequests,
 				Unresolved: unresolvedRequests,
 				Failed:     failedRequests,
+				Metadata:   metadata,
 			}
 
 			// If positive set - move to another table (remove from general cases)
@@ -229,20 +290,32 @@ func (db *DB) GetStatistics(ignoreUnresolved, nonBlockedAsPassed bool) *Statisti
 			ResponseStatusCode: blockedTest.ResponseStatusCode,
 			AdditionalInfo:     blockedTest.AdditionalInfo,
 			Type:               blockedTest.Type,
+			Confidence:         blockedTest.Confidence,
+			BlockedBy:          blockedTest.BlockedBy,
+			MatchedRule:        blockedTest.MatchedRule,
+			Latency:            blockedTest.Latency,
+			Tarpitted:          blockedTest.Tarpitted,
+			Evidence:           blockedTest.Evidence,
+			Category:           blockedTest.category(),
+			Metadata:           blockedTest.Metadata,
 		}
 
-		if isFalsePositiveTest(blockedTest.Set) {
+		if blockedTest.isFalsePositive() {
 			s.TrueNegativeTests.Blocked = append(s.TrueNegativeTests.Blocked, testDetails)
+			s.TrueNegativeTests.countBlockedBy(blockedTest.BlockedBy)
+			s.TrueNegativeTests.countMatchedRule(blockedTest.MatchedRule)
 
-			if isApiTest(blockedTest.Set) {
+			if blockedTest.category() == CategoryAPI {
 				s.TrueNegativeTests.ApiSecReqStats.BlockedRequestsNumber += 1
 			} else {
 				s.TrueNegativeTests.AppSecReqStats.BlockedRequestsNumber += 1
 			}
 		} else {
 			s.TruePositiveTests.Blocked = append(s.TruePositiveTests.Blocked, testDetails)
+			s.TruePositiveTests.countBlockedBy(blockedTest.BlockedBy)
+			s.TruePositiveTests.countMatchedRule(blockedTest.MatchedRule)
 
-			if isApiTest(blockedTest.Set) {
+			if blockedTest.category() == CategoryAPI {
 				s.TruePositiveTests.ApiSecReqStats.BlockedRequestsNumber += 1
 			} else {
 				s.TruePositiveTests.AppSecReqStats.BlockedRequestsNumber += 1
@@ -262,20 +335,30 @@ func (db *DB) GetStatistics(ignoreUnresolved, nonBlockedAsPassed bool) *Statisti
 			ResponseStatusCode: passedTest.ResponseStatusCode,
 			AdditionalInfo:     passedTest.AdditionalInfo,
 			Type:               passedTest.Type,
+			Confidence:         passedTest.Confidence,
+			BlockedBy:          passedTest.BlockedBy,
+			MatchedRule:        passedTest.MatchedRule,
+			Latency:            passedTest.Latency,
+			Tarpitted:          passedTest.Tarpitted,
+			Evidence:           passedTest.Evidence,
+			Category:           passedTest.category(),
+			Metadata:           passedTest.Metadata,
 		}
 
-		if isFalsePositiveTest(passedTest.Set) {
+		if passedTest.isFalsePositive() {
 			s.TrueNegativeTests.Bypasses = append(s.TrueNegativeTests.Bypasses, testDetails)
+			s.TrueNegativeTests.countMatchedRule(passedTest.MatchedRule)
 
-			if isApiTest(passedTest.Set) {
+			if passedTest.category() == CategoryAPI {
 				s.TrueNegativeTests.ApiSecReqStats.BypassedRequestsNumber += 1
 			} else {
 				s.TrueNegativeTests.AppSecReqStats.BypassedRequestsNumber += 1
 			}
 		} else {
 			s.TruePositiveTests.Bypasses = append(s.TruePositiveTests.Bypasses, testDetails)
+			s.TruePositiveTests.countMatchedRule(passedTest.MatchedRule)
 
-			if isApiTest(passedTest.Set) {
+			if passedTest.category() == CategoryAPI {
 				s.TruePositiveTests.ApiSecReqStats.BypassedRequestsNumber += 1
 			} else {
 				s.TruePositiveTests.AppSecReqStats.BypassedRequestsNumber += 1
@@ -295,13 +378,22 @@ func (db *DB) GetStatistics(ignoreUnresolved, nonBlockedAsPassed bool) *Statisti
 			ResponseStatusCode: unresolvedTest.ResponseStatusCode,
 			AdditionalInfo:     unresolvedTest.AdditionalInfo,
 			Type:               unresolvedTest.Type,
+			Confidence:         unresolvedTest.Confidence,
+			BlockedBy:          unresolvedTest.BlockedBy,
+			MatchedRule:        unresolvedTest.MatchedRule,
+			Latency:            unresolvedTest.Latency,
+			Tarpitted:          unresolvedTest.Tarpitted,
+			Evidence:           unresolvedTest.Evidence,
+			Category:           unresolvedTest.category(),
+			Metadata:           unresolvedTest.Metadata,
+			AppRejected:        unresolvedTest.AppRejected,
 		}
 
 		if ignoreUnresolved || nonBlockedAsPassed {
-			if isFalsePositiveTest(unresolvedTest.Set) {
+			if unresolvedTest.isFalsePositive() {
 				s.TrueNegativeTests.Blocked = append(s.TrueNegativeTests.Blocked, testDetails)
 
-				if isApiTest(unresolvedTest.Set) {
+				if unresolvedTest.category() == CategoryAPI {
 					s.TrueNegativeTests.ApiSecReqStats.BlockedRequestsNumber += 1
 				} else {
 					s.TrueNegativeTests.AppSecReqStats.BlockedRequestsNumber += 1
@@ -309,17 +401,25 @@ func (db *DB) GetStatistics(ignoreUnresolved, nonBlockedAsPassed bool) *Statisti
 			} else {
 				s.TruePositiveTests.Bypasses = append(s.TruePositiveTests.Bypasses, testDetails)
 
-				if isApiTest(unresolvedTest.Set) {
+				if unresolvedTest.category() == CategoryAPI {
 					s.TruePositiveTests.ApiSecReqStats.BypassedRequestsNumber += 1
 				} else {
 					s.TruePositiveTests.AppSecReqStats.BypassedRequestsNumber += 1
 				}
 			}
 		} else {
-			if isFalsePositiveTest(unresolvedTest.Set) {
+			if unresolvedTest.isFalsePositive() {
 				s.TrueNegativeTests.Unresolved = append(s.TrueNegativeTests.Unresolved, testDetails)
 
-				if isApiTest(unresolvedTest.Set) {
+				if unresolvedTest.AppRejected {
+					s.TrueNegativeTests.AppRejectedRequestsNumber += 1
+				}
+
+				if unresolvedTest.Tarpitted {
+					s.TrueNegativeTests.TarpittedRequestsNumber += 1
+				}
+
+				if unresolvedTest.category() == CategoryAPI {
 					s.TrueNegativeTests.ApiSecReqStats.UnresolvedRequestsNumber += 1
 				} else {
 					s.TrueNegativeTests.AppSecReqStats.UnresolvedRequestsNumber += 1
@@ -327,7 +427,15 @@ func (db *DB) GetStatistics(ignoreUnresolved, nonBlockedAsPassed bool) *Statisti
 			} else {
 				s.TruePositiveTests.Unresolved = append(s.TruePositiveTests.Unresolved, testDetails)
 
-				if isApiTest(unresolvedTest.Set) {
+				if unresolvedTest.AppRejected {
+					s.TruePositiveTests.AppRejectedRequestsNumber += 1
+				}
+
+				if unresolvedTest.Tarpitted {
+					s.TruePositiveTests.TarpittedRequestsNumber += 1
+				}
+
+				if unresolvedTest.category() == CategoryAPI {
 					s.TruePositiveTests.ApiSecReqStats.UnresolvedRequestsNumber += 1
 				} else {
 					s.TruePositiveTests.AppSecReqStats.UnresolvedRequestsNumber += 1
@@ -336,6 +444,11 @@ func (db *DB) GetStatistics(ignoreUnresolved, nonBlockedAsPassed bool) *Statisti
 		}
 	}
 
+	sortBySeverity(s.TruePositiveTests.Bypasses)
+	sortBySeverity(s.TruePositiveTests.Unresolved)
+
+	s.Latency = latencyDistributions(db.blockedTests, db.passedTests, db.naTests)
+
 	for _, failedTest := range db.failedTests {
 		testDetails := &FailedDetails{
 			Payload:     failedTest.Payload,
@@ -345,12 +458,13 @@ func (db *DB) GetStatistics(ignoreUnresolved, nonBlockedAsPassed bool) *Statisti
 			Placeholder: failedTest.Placeholder,
 			Reason:      failedTest.AdditionalInfo,
 			Type:        failedTest.Type,
+			Metadata:    failedTest.Metadata,
 		}
 
-		if isFalsePositiveTest(failedTest.Set) {
+		if failedTest.isFalsePositive() {
 			s.TrueNegativeTests.Failed = append(s.TrueNegativeTests.Failed, testDetails)
 
-			if isApiTest(failedTest.Set) {
+			if failedTest.category() == CategoryAPI {
 				s.TrueNegativeTests.ApiSecReqStats.FailedRequestsNumber += 1
 			} else {
 				s.TrueNegativeTests.AppSecReqStats.FailedRequestsNumber += 1
@@ -358,7 +472,7 @@ func (db *DB) GetStatistics(ignoreUnresolved, nonBlockedAsPassed bool) *Statisti
 		} else {
 			s.TruePositiveTests.Failed = append(s.TruePositiveTests.Failed, testDetails)
 
-			if isApiTest(failedTest.Set) {
+			if failedTest.category() == CategoryAPI {
 				s.TruePositiveTests.ApiSecReqStats.FailedRequestsNumber += 1
 			} else {
 				s.TruePositiveTests.AppSecReqStats.FailedRequestsNumber += 1
@@ -421,6 +535,80 @@ func (db *DB) GetStatistics(ignoreUnresolved, nonBlockedAsPassed bool) *Statisti
 	return s
 }
 
+// sortBySeverity sorts the tests from the most to the least severe, the
+// order of the tests with the same severity is kept.
+func sortBySeverity(tests []*TestDetails) {
+	sort.SliceStable(tests, func(i, j int) bool {
+		return SeverityRank(tests[i].Severity) > SeverityRank(tests[j].Severity)
+	})
+}
+
+// countBlockedBy counts the blocked request for each WAF in wafNames.
+func (s *TestsSummary) countBlockedBy(wafNames []string) {
+	for _, name := range wafNames {
+		if s.BlockedByWAF == nil {
+			s.BlockedByWAF = make(map[string]int)
+		}
+
+		s.BlockedByWAF[name]++
+	}
+}
+
+// latencyDistributions returns the response time distribution of the tests
+// for each test set. Tests without the measured latency are skipped.
+func latencyDistributions(tests ...[]*Info) map[string]*LatencyDistribution {
+	latencies := make(map[string][]time.Duration)
+	for _, list := range tests {
+		for _, t := range list {
+			if t.Latency > 0 {
+				latencies[t.Set] = append(latencies[t.Set], t.Latency)
+			}
+		}
+	}
+
+	if len(latencies) == 0 {
+		return nil
+	}
+
+	distributions := make(map[string]*LatencyDistribution, len(latencies))
+	for testSet, l := range latencies {
+		sort.Slice(l, func(i, j int) bool { return l[i] < l[j] })
+
+		distributions[testSet] = &LatencyDistribution{
+			Requests: len(l),
+			Min:      l[0],
+			Median:   percentile(l, 50),
+			P90:      percentile(l, 90),
+			Max:      l[len(l)-1],
+		}
+	}
+
+	return distributions
+}
+
+// percentile returns the nearest-rank percentile of the sorted values.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
+
+// countMatchedRule counts the test classified by the rule.
+func (s *TestsSummary) countMatchedRule(ruleName string) {
+	if ruleName == "" {
+		return
+	}
+
+	if s.MatchedRules == nil {
+		s.MatchedRules = make(map[string]int)
+	}
+
+	s.MatchedRules[ruleName]++
+}
+
 func calculateTestsSummaryStat(s *TestsSummary) {
 	// All requests stat
 	s.ReqStats.AllRequestsNumber = s.ReqStats.BlockedRequestsNumber +
@@ -650,3 +650,67 @@ func TestStatisticsCalculation(t *testing.T) {
 		}
 	}
 }
+
+func TestStatisticsSeverity(t *testing.T) {
+	tests := []*Case{
+		{Set: "owasp", Name: "low", Metadata: Metadata{Severity: SeverityLow}},
+		{Set: "owasp", Name: "none"},
+		{Set: "owasp", Name: "critical", Metadata: Metadata{Severity: SeverityCritical, CWE: 89}},
+	}
+
+	db, err := NewDB(tests)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range tests {
+		db.UpdatePassedTests(&Info{Set: tc.Set, Case: tc.Name, Metadata: tc.Metadata})
+	}
+
+	stat := db.GetStatistics(false, false)
+
+	var bypasses []string
+	for _, b := range stat.TruePositiveTests.Bypasses {
+		bypasses = append(bypasses, b.TestCase)
+	}
+	if fmt.Sprint(bypasses) != "[critical low none]" {
+		t.Errorf("got bypasses %v, want them sorted by severity", bypasses)
+	}
+
+	for _, row := range stat.TruePositiveTests.SummaryTable {
+		if row.TestCase == "critical" && (row.Severity != SeverityCritical || row.CWE != 89) {
+			t.Errorf("got metadata %+v for the %s test case", row.Metadata, row.TestCase)
+		}
+	}
+}
+
+func TestStatisticsExpectation(t *testing.T) {
+	tests := []*Case{
+		{Set: "mixed", Name: "attack", IsTruePositive: true, Category: CategoryAPI},
+		{Set: "mixed", Name: "benign", IsTruePositive: false, Category: CategoryApp},
+	}
+
+	db, err := NewDB(tests)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db.UpdateBlockedTests(&Info{Set: "mixed", Case: "attack", Expect: ExpectBlock, Category: CategoryAPI})
+	db.UpdatePassedTests(&Info{Set: "mixed", Case: "benign", Expect: ExpectPass, Category: CategoryApp})
+
+	stat := db.GetStatistics(false, false)
+
+	if len(stat.TruePositiveTests.SummaryTable) != 1 || stat.TruePositiveTests.SummaryTable[0].TestCase != "attack" {
+		t.Errorf("got true-positive summary %+v, want the attack test case", stat.TruePositiveTests.SummaryTable)
+	}
+	if len(stat.TrueNegativeTests.SummaryTable) != 1 || stat.TrueNegativeTests.SummaryTable[0].TestCase != "benign" {
+		t.Errorf("got true-negative summary %+v, want the benign test case", stat.TrueNegativeTests.SummaryTable)
+	}
+
+	if stat.Score.ApiSec.TruePositive != 100 || stat.Score.ApiSec.TrueNegative != -1 {
+		t.Errorf("got ApiSec score %+v", stat.Score.ApiSec)
+	}
+	if stat.Score.AppSec.TruePositive != -1 || stat.Score.AppSec.TrueNegative != 100 {
+		t.Errorf("got AppSec score %+v", stat.Score.AppSec)
+	}
+}
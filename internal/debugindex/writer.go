@@ -0,0 +1,82 @@
+// Package debugindex writes the index which maps the values of the debug
+// header to the test information, so the requests found in the WAF logs can
+// be matched with the tests.
+package debugindex
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/wallarm/gotestwaf/internal/helpers"
+)
+
+// Entry describes the test request sent with the debug header.
+type Entry struct {
+	Hash        string    `json:"hash"`
+	Set         string    `json:"set"`
+	Case        string    `json:"case"`
+	Payload     string    `json:"payload"`
+	Encoder     string    `json:"encoder"`
+	Placeholder string    `json:"placeholder"`
+	Timestamp   time.Time `json:"timestamp"`
+	Result      string    `json:"result"`
+}
+
+// Writer streams the index entries as JSON lines to a temporary file. The
+// file is moved to its final location by Save.
+type Writer struct {
+	mu   sync.Mutex
+	file *helpers.AtomicFile
+	enc  *json.Encoder
+}
+
+// NewWriter creates a temporary index file in the dir.
+func NewWriter(dir string) (*Writer, error) {
+	file, err := helpers.NewAtomicFile(dir, ".gotestwaf-*.jsonl")
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't create debug header index file")
+	}
+
+	enc := json.NewEncoder(file)
+	enc.SetEscapeHTML(false)
+
+	return &Writer{
+		file: file,
+		enc:  enc,
+	}, nil
+}
+
+// Add writes the entry to the file. It is safe for concurrent use.
+func (w *Writer) Add(entry *Entry) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.enc.Encode(entry); err != nil {
+		return errors.Wrap(err, "couldn't write debug header index entry")
+	}
+
+	return nil
+}
+
+// Save moves the index file to the filename.
+func (w *Writer) Save(filename string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.file.Save(filename); err != nil {
+		return errors.Wrap(err, "couldn't save debug header index file")
+	}
+
+	return nil
+}
+
+// Close removes the temporary file if the index wasn't saved.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.file.Close()
+}
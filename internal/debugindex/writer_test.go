@@ -0,0 +1,74 @@
+package debugindex
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWriter(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := NewWriter(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := w.Add(&Entry{
+				Hash:      "6f44127775022160ec357620eb5b996b3aee5019600ab54ed240588021554a1d",
+				Set:       "owasp",
+				Payload:   "<script>alert(1)</script>",
+				Timestamp: time.Now(),
+				Result:    "blocked",
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	filename := filepath.Join(dir, "report.debug.jsonl")
+	if err = w.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = w.Add(&Entry{}); err == nil {
+		t.Errorf("entry is added to the saved index")
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var lines int
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry Entry
+		if err = json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		if entry.Payload != "<script>alert(1)</script>" || entry.Result != "blocked" {
+			t.Errorf("got entry %+v", entry)
+		}
+
+		lines++
+	}
+
+	if lines != 10 {
+		t.Errorf("got %d lines, want 10", lines)
+	}
+}
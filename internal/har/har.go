@@ -0,0 +1,201 @@
+// Package har records the requests sent during the scan and the received
+// responses in the HTTP Archive (HAR) 1.2 format.
+package har
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/wallarm/gotestwaf/internal/scanner/types"
+)
+
+const harVersion = "1.2"
+
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type Entry struct {
+	StartedDateTime string    `json:"startedDateTime"`
+	Time            float64   `json:"time"`
+	Request         *Request  `json:"request"`
+	Response        *Response `json:"response"`
+	Cache           struct{}  `json:"cache"`
+	Timings         *Timings  `json:"timings"`
+
+	// Test is a custom field with the test info and the classification of
+	// the response.
+	Test *Test `json:"_test,omitempty"`
+}
+
+type Request struct {
+	Method      string       `json:"method"`
+	URL         string       `json:"url"`
+	HTTPVersion string       `json:"httpVersion"`
+	Cookies     []*NameValue `json:"cookies"`
+	Headers     []*NameValue `json:"headers"`
+	QueryString []*NameValue `json:"queryString"`
+	PostData    *PostData    `json:"postData,omitempty"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+}
+
+type Response struct {
+	Status      int          `json:"status"`
+	StatusText  string       `json:"statusText"`
+	HTTPVersion string       `json:"httpVersion"`
+	Cookies     []*NameValue `json:"cookies"`
+	Headers     []*NameValue `json:"headers"`
+	Content     *Content     `json:"content"`
+	RedirectURL string       `json:"redirectURL"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+}
+
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type PostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type Content struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type Timings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// Test describes the test the request was sent for.
+type Test struct {
+	Set         string `json:"set"`
+	Case        string `json:"case"`
+	Payload     string `json:"payload"`
+	Encoder     string `json:"encoder"`
+	Placeholder string `json:"placeholder"`
+	Type        string `json:"type"`
+	Result      string `json:"result"`
+	Rule        string `json:"rule,omitempty"`
+}
+
+// NewEntry creates a HAR entry from the response and the request recorded
+// by the HTTP client. It returns nil if the request wasn't recorded.
+func NewEntry(resp types.Response, test *Test) *Entry {
+	req := resp.GetRequest()
+	if req == nil {
+		return nil
+	}
+
+	latency := resp.GetLatency()
+	started := time.Now().Add(-latency)
+	latencyMs := float64(latency) / float64(time.Millisecond)
+
+	content := resp.GetContent()
+	headers := resp.GetHeaders()
+
+	entry := &Entry{
+		StartedDateTime: started.Format(time.RFC3339Nano),
+		Time:            latencyMs,
+		Request: &Request{
+			Method:      req.Method,
+			URL:         req.URL,
+			HTTPVersion: req.Proto,
+			Cookies:     []*NameValue{},
+			Headers:     nameValues(req.Headers),
+			QueryString: queryString(req.URL),
+			HeadersSize: -1,
+			BodySize:    len(req.Body),
+		},
+		Response: &Response{
+			Status:      resp.GetStatusCode(),
+			StatusText:  resp.GetReason(),
+			HTTPVersion: req.Proto,
+			Cookies:     []*NameValue{},
+			Headers:     nameValues(headers),
+			Content:     newContent(content, headers.Get("Content-Type")),
+			RedirectURL: headers.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    len(content),
+		},
+		Timings: &Timings{
+			Send:    0,
+			Wait:    latencyMs,
+			Receive: 0,
+		},
+		Test: test,
+	}
+
+	if len(req.Body) > 0 {
+		entry.Request.PostData = &PostData{
+			MimeType: req.Headers.Get("Content-Type"),
+			Text:     string(req.Body),
+		}
+	}
+
+	return entry
+}
+
+// newContent returns the response content. The binary content is encoded
+// with base64.
+func newContent(body []byte, mimeType string) *Content {
+	content := &Content{
+		Size:     len(body),
+		MimeType: mimeType,
+	}
+
+	if utf8.Valid(body) {
+		content.Text = string(body)
+	} else {
+		content.Text = base64.StdEncoding.EncodeToString(body)
+		content.Encoding = "base64"
+	}
+
+	return content
+}
+
+// nameValues converts the headers to the list sorted by name.
+func nameValues(headers http.Header) []*NameValue {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := make([]*NameValue, 0, len(headers))
+	for _, name := range names {
+		for _, value := range headers[name] {
+			list = append(list, &NameValue{Name: name, Value: value})
+		}
+	}
+
+	return list
+}
+
+func queryString(rawURL string) []*NameValue {
+	list := []*NameValue{}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return list
+	}
+
+	// Payloads often break the query, the valid parameters are returned
+	// anyway
+	query, _ := url.ParseQuery(u.RawQuery)
+
+	return append(list, nameValues(http.Header(query))...)
+}
@@ -0,0 +1,93 @@
+package har
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/wallarm/gotestwaf/internal/helpers"
+	"github.com/wallarm/gotestwaf/internal/version"
+)
+
+// Writer streams HAR entries to a temporary file, so the entries are not
+// kept in memory during the scan. The file is moved to its final location
+// by Save.
+type Writer struct {
+	mu      sync.Mutex
+	file    *helpers.AtomicFile
+	entries int
+}
+
+// NewWriter creates a temporary HAR file in the dir.
+func NewWriter(dir string) (*Writer, error) {
+	file, err := helpers.NewAtomicFile(dir, ".gotestwaf-*.har")
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't create HAR file")
+	}
+
+	w := &Writer{file: file}
+
+	creator, err := json.Marshal(&Creator{Name: "GoTestWAF", Version: version.Version})
+	if err != nil {
+		w.Close()
+		return nil, err
+	}
+
+	_, err = fmt.Fprintf(w.file, `{"log":{"version":"%s","creator":%s,"entries":[`, harVersion, creator)
+	if err != nil {
+		w.Close()
+		return nil, errors.Wrap(err, "couldn't write HAR file")
+	}
+
+	return w, nil
+}
+
+// Add writes the entry to the file. It is safe for concurrent use.
+func (w *Writer) Add(entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "couldn't marshal HAR entry")
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.entries > 0 {
+		data = append([]byte{','}, data...)
+	}
+
+	if _, err = w.file.Write(data); err != nil {
+		return errors.Wrap(err, "couldn't write HAR entry")
+	}
+
+	w.entries++
+
+	return nil
+}
+
+// Save completes the HAR file and moves it to the filename.
+func (w *Writer) Save(filename string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, err := w.file.Write([]byte("]}}")); err != nil {
+		w.file.Close()
+		return errors.Wrap(err, "couldn't write HAR file")
+	}
+
+	if err := w.file.Save(filename); err != nil {
+		return errors.Wrap(err, "couldn't save HAR file")
+	}
+
+	return nil
+}
+
+// Close removes the temporary file if the HAR file wasn't saved.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.file.Close()
+}
@@ -0,0 +1,98 @@
+package har
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wallarm/gotestwaf/internal/scanner/types"
+)
+
+func TestWriter(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := NewWriter(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	resp := &types.ResponseMeta{
+		StatusCode:   http.StatusForbidden,
+		StatusReason: "Forbidden",
+		Headers:      http.Header{"Content-Type": {"text/html"}},
+		Content:      []byte("blocked"),
+		Latency:      100 * time.Millisecond,
+		Request: &types.RequestMeta{
+			Method:  http.MethodPost,
+			URL:     "http://example.com/?a=1&b=<script>",
+			Proto:   "HTTP/1.1",
+			Headers: http.Header{"Content-Type": {"application/json"}},
+			Body:    []byte(`{"a":"' or 1=1"}`),
+		},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := w.Add(NewEntry(resp, &Test{Set: "community", Result: "blocked"})); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if NewEntry(&types.ResponseMeta{}, nil) != nil {
+		t.Errorf("entry is created for the response without the recorded request")
+	}
+
+	filename := filepath.Join(dir, "report.har")
+	if err = w.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var har struct {
+		Log struct {
+			Version string   `json:"version"`
+			Entries []*Entry `json:"entries"`
+		} `json:"log"`
+	}
+	if err = json.Unmarshal(data, &har); err != nil {
+		t.Fatalf("invalid HAR file: %v", err)
+	}
+
+	if len(har.Log.Entries) != 10 {
+		t.Fatalf("got %d entries, want 10", len(har.Log.Entries))
+	}
+
+	entry := har.Log.Entries[0]
+	if entry.Request.PostData == nil || entry.Request.PostData.Text != string(resp.Request.Body) {
+		t.Errorf("got post data %+v, want %q", entry.Request.PostData, resp.Request.Body)
+	}
+	if len(entry.Request.QueryString) != 2 {
+		t.Errorf("got %d query parameters, want 2", len(entry.Request.QueryString))
+	}
+	if entry.Response.Status != http.StatusForbidden || entry.Response.Content.Text != "blocked" {
+		t.Errorf("got response %d %q", entry.Response.Status, entry.Response.Content.Text)
+	}
+	if entry.Test == nil || entry.Test.Result != "blocked" {
+		t.Errorf("got test %+v, want blocked result", entry.Test)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.har"))
+	if len(matches) != 1 {
+		t.Errorf("got HAR files %v, want only the saved one", matches)
+	}
+}
@@ -0,0 +1,95 @@
+package helpers
+
+import (
+	"bufio"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// AtomicFile is written to a temporary file which is moved to its final
+// location by Save, so an incomplete file is never left at that location.
+// The writes are buffered. It isn't safe for concurrent use.
+type AtomicFile struct {
+	file   *os.File
+	buf    *bufio.Writer
+	closed bool
+}
+
+// NewAtomicFile creates a temporary file in the dir. The name is built from
+// the pattern as by os.CreateTemp, but the file is created with the same
+// permissions as by os.Create, so the saved file respects the umask.
+func NewAtomicFile(dir, pattern string) (*AtomicFile, error) {
+	prefix, suffix, _ := strings.Cut(pattern, "*")
+
+	for try := 0; ; try++ {
+		name := filepath.Join(dir, prefix+strconv.FormatUint(uint64(rand.Uint32()), 10)+suffix)
+
+		file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if os.IsExist(err) && try < 100 {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return &AtomicFile{
+			file: file,
+			buf:  bufio.NewWriter(file),
+		}, nil
+	}
+}
+
+// Write writes p to the temporary file.
+func (f *AtomicFile) Write(p []byte) (int, error) {
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+
+	return f.buf.Write(p)
+}
+
+// Save moves the file to the filename. The temporary file is removed if it
+// couldn't be moved.
+func (f *AtomicFile) Save(filename string) error {
+	if f.closed {
+		return os.ErrClosed
+	}
+	f.closed = true
+
+	err := f.save(filename)
+	if err != nil {
+		f.file.Close()
+		os.Remove(f.file.Name())
+	}
+
+	return err
+}
+
+func (f *AtomicFile) save(filename string) error {
+	if err := f.buf.Flush(); err != nil {
+		return errors.Wrap(err, "couldn't write file")
+	}
+
+	if err := f.file.Close(); err != nil {
+		return errors.Wrap(err, "couldn't close file")
+	}
+
+	return os.Rename(f.file.Name(), filename)
+}
+
+// Close removes the temporary file if the file wasn't saved.
+func (f *AtomicFile) Close() error {
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+
+	f.file.Close()
+
+	return os.Remove(f.file.Name())
+}
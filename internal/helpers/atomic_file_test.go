@@ -0,0 +1,78 @@
+package helpers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAtomicFile(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "report.jsonl")
+
+	f, err := NewAtomicFile(dir, ".gotestwaf-*.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, err = fmt.Fprint(f, "data"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = os.Stat(filename); !os.IsNotExist(err) {
+		t.Errorf("file exists before it is saved")
+	}
+
+	if err = f.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "data" {
+		t.Errorf("got %q, want %q", data, "data")
+	}
+
+	if _, err = f.Write([]byte("data")); err == nil {
+		t.Errorf("data is written to the saved file")
+	}
+
+	created, err := os.Create(filepath.Join(dir, "created"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	created.Close()
+
+	createdStat, err := os.Stat(created.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	savedStat, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if savedStat.Mode() != createdStat.Mode() {
+		t.Errorf("saved file mode is %s, want %s as os.Create", savedStat.Mode(), createdStat.Mode())
+	}
+
+	// The temporary file of the unsaved file is removed
+	f, err = NewAtomicFile(dir, ".gotestwaf-*.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("got %d files, want 2", len(entries))
+	}
+}
@@ -0,0 +1,111 @@
+package helpers
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/wallarm/gotestwaf/internal/config"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ParseTLSVersion converts a TLS version string (e.g. "1.2") to the
+// corresponding crypto/tls constant. An empty string returns 0, which means
+// that the crypto/tls default will be used.
+func ParseTLSVersion(version string) (uint16, error) {
+	if version == "" {
+		return 0, nil
+	}
+
+	v, ok := tlsVersions[strings.TrimPrefix(strings.ToLower(version), "tls")]
+	if !ok {
+		return 0, fmt.Errorf("unknown TLS version: %s, expected 1.0, 1.1, 1.2 or 1.3", version)
+	}
+
+	return v, nil
+}
+
+// VerifyServerCert reports whether the server certificate must be verified.
+// A custom CA bundle implies the verification, it would be unused otherwise.
+func VerifyServerCert(cfg *config.Config) bool {
+	return cfg.TLSVerify || cfg.TLSCA != ""
+}
+
+// NewTLSConfig builds the TLS client configuration shared by all clients
+// (HTTP, GraphQL, gRPC and the WAF detector) from the GoTestWAF config.
+func NewTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	tlsConf := &tls.Config{
+		InsecureSkipVerify: !VerifyServerCert(cfg),
+		ServerName:         cfg.TLSServerName,
+	}
+
+	minVersion, err := ParseTLSVersion(cfg.TLSMinVersion)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't parse minimum TLS version")
+	}
+
+	maxVersion, err := ParseTLSVersion(cfg.TLSMaxVersion)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't parse maximum TLS version")
+	}
+
+	if minVersion != 0 && maxVersion != 0 && minVersion > maxVersion {
+		return nil, errors.Errorf(
+			"minimum TLS version %s is greater than maximum TLS version %s",
+			cfg.TLSMinVersion, cfg.TLSMaxVersion,
+		)
+	}
+
+	tlsConf.MinVersion = minVersion
+	tlsConf.MaxVersion = maxVersion
+
+	if cfg.TLSClientCert != "" || cfg.TLSClientKey != "" {
+		if cfg.TLSClientCert == "" || cfg.TLSClientKey == "" {
+			return nil, errors.New("both client certificate and client key must be set")
+		}
+
+		cert, err := tls.LoadX509KeyPair(cfg.TLSClientCert, cfg.TLSClientKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't load client certificate")
+		}
+
+		tlsConf.Certificates = []tls.Certificate{cert}
+	}
+
+	if cfg.TLSCA != "" {
+		caPool, err := LoadCertPool(cfg.TLSCA)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConf.RootCAs = caPool
+	}
+
+	return tlsConf, nil
+}
+
+// LoadCertPool reads PEM encoded certificates from the given file and returns
+// them as a certificate pool.
+func LoadCertPool(caFile string) (*x509.CertPool, error) {
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't read CA bundle")
+	}
+
+	caPool := x509.NewCertPool()
+	if !caPool.AppendCertsFromPEM(caPEM) {
+		return nil, errors.Errorf("couldn't find any PEM encoded certificates in %s", caFile)
+	}
+
+	return caPool, nil
+}
@@ -0,0 +1,151 @@
+package helpers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wallarm/gotestwaf/internal/config"
+)
+
+func writeTestCertificate(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("couldn't generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "gotestwaf test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("couldn't create certificate: %v", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("couldn't marshal key: %v", err)
+	}
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+
+	err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	if err != nil {
+		t.Fatalf("couldn't write certificate: %v", err)
+	}
+
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	if err != nil {
+		t.Fatalf("couldn't write key: %v", err)
+	}
+
+	return certFile, keyFile
+}
+
+func TestParseTLSVersion(t *testing.T) {
+	testCases := []struct {
+		version string
+		want    uint16
+		isBad   bool
+	}{
+		{version: "", want: 0},
+		{version: "1.0", want: tls.VersionTLS10},
+		{version: "1.2", want: tls.VersionTLS12},
+		{version: "TLS1.3", want: tls.VersionTLS13},
+		{version: "1.4", isBad: true},
+		{version: "ssl3", isBad: true},
+	}
+
+	for _, tc := range testCases {
+		got, err := ParseTLSVersion(tc.version)
+		if tc.isBad {
+			if err == nil {
+				t.Errorf("expected error for version %q", tc.version)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("unexpected error for version %q: %v", tc.version, err)
+		}
+		if got != tc.want {
+			t.Errorf("version %q: got %x, want %x", tc.version, got, tc.want)
+		}
+	}
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t, t.TempDir())
+
+	cfg := &config.Config{
+		TLSVerify:     true,
+		TLSClientCert: certFile,
+		TLSClientKey:  keyFile,
+		TLSCA:         certFile,
+		TLSServerName: "example.com",
+		TLSMinVersion: "1.2",
+		TLSMaxVersion: "1.3",
+	}
+
+	tlsConf, err := NewTLSConfig(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if tlsConf.InsecureSkipVerify {
+		t.Error("InsecureSkipVerify must be false when TLS verification is enabled")
+	}
+	if tlsConf.ServerName != "example.com" {
+		t.Errorf("got server name %q, want %q", tlsConf.ServerName, "example.com")
+	}
+	if tlsConf.MinVersion != tls.VersionTLS12 || tlsConf.MaxVersion != tls.VersionTLS13 {
+		t.Errorf("got TLS versions %x-%x", tlsConf.MinVersion, tlsConf.MaxVersion)
+	}
+	if len(tlsConf.Certificates) != 1 {
+		t.Errorf("got %d client certificates, want 1", len(tlsConf.Certificates))
+	}
+	if tlsConf.RootCAs == nil {
+		t.Error("RootCAs must be set")
+	}
+
+	// The custom CA bundle implies the verification
+	tlsConf, err = NewTLSConfig(&config.Config{TLSCA: certFile})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tlsConf.InsecureSkipVerify {
+		t.Error("InsecureSkipVerify must be false when the CA bundle is set")
+	}
+
+	badConfigs := []*config.Config{
+		{TLSClientCert: certFile},
+		{TLSClientKey: keyFile},
+		{TLSCA: keyFile},
+		{TLSMinVersion: "1.3", TLSMaxVersion: "1.2"},
+		{TLSMinVersion: "2.0"},
+	}
+
+	for i, badCfg := range badConfigs {
+		if _, err = NewTLSConfig(badCfg); err == nil {
+			t.Errorf("expected error for config #%d", i)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package importer
+
+import (
+	"io/fs"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/wallarm/gotestwaf/internal/db"
+)
+
+// crsRuleFileRegex matches the directory of the CRS regression tests of a
+// rule file, e.g. REQUEST-942-APPLICATION-ATTACK-SQLI, the submatch is used
+// as the test type
+var crsRuleFileRegex = regexp.MustCompile(`^(REQUEST|RESPONSE)-\d+-(?:APPLICATION-ATTACK-)?(.+)$`)
+
+// ftwFile is the go-ftw test file. Both the current format and the older one
+// with the stage wrapper and log_contains are supported.
+type ftwFile struct {
+	Meta struct {
+		Enabled *bool `yaml:"enabled"`
+	} `yaml:"meta"`
+	RuleID int `yaml:"rule_id"`
+	Tests  []struct {
+		Stages []struct {
+			Input  *ftwInput  `yaml:"input"`
+			Output *ftwOutput `yaml:"output"`
+			Stage  *struct {
+				Input  *ftwInput  `yaml:"input"`
+				Output *ftwOutput `yaml:"output"`
+			} `yaml:"stage"`
+		} `yaml:"stages"`
+	} `yaml:"tests"`
+}
+
+type ftwInput struct {
+	Method         string            `yaml:"method"`
+	URI            string            `yaml:"uri"`
+	Headers        map[string]string `yaml:"headers"`
+	Data           any               `yaml:"data"`
+	EncodedRequest string            `yaml:"encoded_request"`
+	RawRequest     string            `yaml:"raw_request"`
+}
+
+type ftwOutput struct {
+	Log struct {
+		ExpectIDs   []int `yaml:"expect_ids"`
+		NoExpectIDs []int `yaml:"no_expect_ids"`
+	} `yaml:"log"`
+	LogContains   string `yaml:"log_contains"`
+	NoLogContains string `yaml:"no_log_contains"`
+	Status        any    `yaml:"status"`
+}
+
+// ImportCRS converts the OWASP CRS regression tests in the go-ftw format
+// found in paths to the test cases. Each stage of a test is imported as a
+// request with the body or, if there is no body, the query string as the
+// payload. The stages with the same request and the expected outcome are
+// grouped into a test case named after the rule. The number of skipped
+// stages, which can't be represented as a test, is returned.
+func ImportCRS(paths []string) (testCases []*TestCase, skipped int, err error) {
+	b := newTestCaseBuilder()
+
+	for _, root := range paths {
+		err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			ext := filepath.Ext(path)
+			if d.IsDir() || (ext != ".yaml" && ext != ".yml") {
+				return nil
+			}
+
+			n, importErr := importCRSFile(b, path)
+			if importErr != nil {
+				return errors.Wrapf(importErr, "couldn't import %s", path)
+			}
+
+			skipped += n
+
+			return nil
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return b.result(), skipped, nil
+}
+
+func importCRSFile(b *testCaseBuilder, path string) (skipped int, err error) {
+	typ := "unknown"
+
+	// The tests of the response rules can't be triggered by the requests
+	if m := crsRuleFileRegex.FindStringSubmatch(filepath.Base(filepath.Dir(path))); m != nil {
+		if m[1] == "RESPONSE" {
+			return 0, nil
+		}
+
+		typ = m[2]
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var f ftwFile
+	if err = yaml.Unmarshal(data, &f); err != nil {
+		return 0, err
+	}
+
+	if f.Meta.Enabled != nil && !*f.Meta.Enabled {
+		return 0, nil
+	}
+
+	group := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	tags := []string{"crs"}
+
+	if f.RuleID != 0 {
+		group = strconv.Itoa(f.RuleID)
+		tags = append(tags, group)
+	}
+
+	for _, t := range f.Tests {
+		for _, s := range t.Stages {
+			input, output := s.Input, s.Output
+			if s.Stage != nil {
+				input, output = s.Stage.Input, s.Stage.Output
+			}
+
+			if input == nil || output == nil {
+				skipped++
+				continue
+			}
+
+			expect := output.expect()
+			payload, req, ok := input.request()
+
+			if expect == "" || !ok {
+				skipped++
+				continue
+			}
+
+			b.add(group, payload, req, typ, expect, tags)
+		}
+	}
+
+	return skipped, nil
+}
+
+// request converts the input to the request and the payload. It returns false
+// if the payload can't be extracted.
+func (in *ftwInput) request() (payload string, req *RawRequest, ok bool) {
+	if in.EncodedRequest != "" || in.RawRequest != "" {
+		return "", nil, false
+	}
+
+	req = &RawRequest{
+		Method:  in.Method,
+		Path:    in.URI,
+		Headers: make(map[string]string),
+	}
+
+	if req.Method == "" {
+		req.Method = "GET"
+	}
+
+	if req.Path == "" {
+		req.Path = "/"
+	}
+
+	for header, value := range in.Headers {
+		if !isSkippedHeader(header) {
+			req.Headers[header] = value
+		}
+	}
+
+	if body := in.body(); body != "" {
+		req.Body = PayloadMarker
+		return body, req, true
+	}
+
+	path, query, found := strings.Cut(req.Path, "?")
+	if !found || query == "" {
+		return "", nil, false
+	}
+
+	// The payload in the path is escaped by the RawRequest placeholder, so
+	// the query string is imported only if the escaped payload is parsed to
+	// the same parameters
+	payload, err := url.PathUnescape(query)
+	if err != nil {
+		return "", nil, false
+	}
+
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return "", nil, false
+	}
+
+	escapedParams, err := url.ParseQuery(url.PathEscape(payload))
+	if err != nil || !maps.EqualFunc(params, escapedParams, slices.Equal) {
+		return "", nil, false
+	}
+
+	req.Path = path + "?" + PayloadMarker
+
+	return payload, req, true
+}
+
+// body returns the request body, the lines of the body are joined with CRLF
+// in the older format.
+func (in *ftwInput) body() string {
+	switch data := in.Data.(type) {
+	case string:
+		return data
+
+	case []any:
+		lines := make([]string, 0, len(data))
+		for _, line := range data {
+			s, _ := line.(string)
+			lines = append(lines, s)
+		}
+
+		return strings.Join(lines, "\r\n")
+	}
+
+	return ""
+}
+
+// expect returns the expected outcome of the stage, an empty string if it is
+// unknown.
+func (o *ftwOutput) expect() string {
+	if len(o.Log.ExpectIDs) > 0 || o.LogContains != "" || o.hasStatus(403) {
+		return db.ExpectBlock
+	}
+
+	if len(o.Log.NoExpectIDs) > 0 || o.NoLogContains != "" {
+		return db.ExpectPass
+	}
+
+	return ""
+}
+
+// hasStatus checks if the status is expected. The expected status is set as
+// a number or a list of numbers.
+func (o *ftwOutput) hasStatus(status int) bool {
+	switch s := o.Status.(type) {
+	case int:
+		return s == status
+
+	case []any:
+		for _, v := range s {
+			if v == status {
+				return true
+			}
+		}
+	}
+
+	return false
+}
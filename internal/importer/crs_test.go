@@ -0,0 +1,122 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wallarm/gotestwaf/internal/config"
+	"github.com/wallarm/gotestwaf/internal/db"
+)
+
+const crsTestFile = `
+rule_id: 942100
+tests:
+  - test_id: 1
+    stages:
+      - input:
+          method: POST
+          uri: /post
+          headers:
+            Host: localhost
+            Content-Type: application/x-www-form-urlencoded
+          data: var=1234 OR 1=1
+        output:
+          log:
+            expect_ids: [942100]
+  - test_id: 2
+    stages:
+      - input:
+          method: POST
+          uri: /post
+          headers:
+            Content-Type: application/x-www-form-urlencoded
+            Host: localhost
+          data: var=1 OR 1=1
+        output:
+          log:
+            expect_ids: [942100]
+  - test_id: 3
+    stages:
+      - input:
+          uri: /get?var=%27%20or%201%3D1--
+        output:
+          log:
+            no_expect_ids: [942100]
+  - test_id: 4
+    stages:
+      - input:
+          uri: /get?var=1%2B1
+        output:
+          log:
+            expect_ids: [942100]
+  - test_id: 5
+    stages:
+      - stage:
+          input:
+            uri: /
+            headers:
+              User-Agent: sqlmap
+          output:
+            status: [403]
+`
+
+func TestImportCRS(t *testing.T) {
+	dir := t.TempDir()
+
+	testsDir := filepath.Join(dir, "tests", "REQUEST-942-APPLICATION-ATTACK-SQLI")
+	if err := os.MkdirAll(testsDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(testsDir, "942100.yaml"), []byte(crsTestFile), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases, skipped, err := ImportCRS([]string{filepath.Join(dir, "tests")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The query string with the escaped "+" and the payload in the header
+	// can't be imported
+	if skipped != 2 {
+		t.Errorf("got %d skipped stages, want 2", skipped)
+	}
+
+	if len(testCases) != 2 {
+		t.Fatalf("got %d test cases, want 2", len(testCases))
+	}
+
+	post, get := testCases[0], testCases[1]
+
+	if post.Name != "942100-1" || post.Expect != db.ExpectBlock || post.Type != "SQLI" || len(post.Payloads) != 2 {
+		t.Errorf("got test case %+v", post)
+	}
+	if post.Requests[0].Body != PayloadMarker || post.Requests[0].Headers["Host"] != "" {
+		t.Errorf("got request %+v", post.Requests[0])
+	}
+
+	if get.Name != "942100-2" || get.Expect != db.ExpectPass || get.Payloads[0] != "var=' or 1=1--" {
+		t.Errorf("got test case %+v", get)
+	}
+	if get.Requests[0].Method != "GET" || get.Requests[0].Path != "/get?"+PayloadMarker {
+		t.Errorf("got request %+v", get.Requests[0])
+	}
+
+	testCasesPath := filepath.Join(dir, "testcases")
+	if err = Write(testCasesPath, "crs", testCases); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := db.LoadTestCases(&config.Config{TestCasesPath: testCasesPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 2 || loaded[0].Placeholders[0].Name != "RawRequest" || loaded[1].IsTruePositive {
+		t.Errorf("couldn't load the imported test cases")
+	}
+
+	if err = Write(testCasesPath, "crs", testCases); err == nil {
+		t.Errorf("no error for the existing test set")
+	}
+}
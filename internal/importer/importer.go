@@ -0,0 +1,227 @@
+// Package importer converts the tests of other tools to GoTestWAF test cases
+// with the RawRequest placeholder.
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/wallarm/gotestwaf/internal/db"
+)
+
+// PayloadMarker is the mark of the payload position in the RawRequest
+// placeholder config.
+const PayloadMarker = "{{payload}}"
+
+// skippedHeaders are the headers of the imported requests which must be set
+// by the HTTP client.
+var skippedHeaders = []string{"Host", "Content-Length"}
+
+// RawRequest is the config of the RawRequest placeholder.
+type RawRequest struct {
+	Method  string            `yaml:"method"`
+	Path    string            `yaml:"path"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Body    string            `yaml:"body,omitempty"`
+}
+
+// key returns the string which is equal for the equal configs.
+func (r *RawRequest) key() string {
+	var b strings.Builder
+
+	b.WriteString(r.Method)
+	b.WriteByte(0)
+	b.WriteString(r.Path)
+	b.WriteByte(0)
+
+	headers := make([]string, 0, len(r.Headers))
+	for header := range r.Headers {
+		headers = append(headers, header)
+	}
+	sort.Strings(headers)
+
+	for _, header := range headers {
+		b.WriteString(header)
+		b.WriteByte(0)
+		b.WriteString(r.Headers[header])
+		b.WriteByte(0)
+	}
+
+	b.WriteString(r.Body)
+
+	return b.String()
+}
+
+// TestCase is the imported test case. Each payload is sent in each request.
+type TestCase struct {
+	Name     string
+	Payloads []string
+	// Encoders are Plain if empty
+	Encoders []string
+	Requests []*RawRequest
+	Type     string
+	Template bool
+	// Expect is db.ExpectBlock or db.ExpectPass
+	Expect   string
+	Category string
+
+	db.Metadata
+}
+
+// testCaseFile is the format of the test case file.
+type testCaseFile struct {
+	Payloads     []string                 `yaml:"payload"`
+	Encoders     []string                 `yaml:"encoder"`
+	Placeholders []map[string]*RawRequest `yaml:"placeholder"`
+	Type         string                   `yaml:"type"`
+	Template     bool                     `yaml:"template,omitempty"`
+	Expect       string                   `yaml:"expect"`
+	Category     string                   `yaml:"category,omitempty"`
+	Severity     string                   `yaml:"severity,omitempty"`
+	CWE          int                      `yaml:"cwe,omitempty"`
+	OWASP        string                   `yaml:"owasp,omitempty"`
+	Tags         []string                 `yaml:"tags,omitempty"`
+	References   []string                 `yaml:"references,omitempty"`
+}
+
+// testCaseBuilder groups the requests with the same config and the same
+// expected outcome into the test cases.
+type testCaseBuilder struct {
+	testCases []*TestCase
+	index     map[string]*TestCase
+	payloads  map[*TestCase]map[string]struct{}
+}
+
+func newTestCaseBuilder() *testCaseBuilder {
+	return &testCaseBuilder{
+		index:    make(map[string]*TestCase),
+		payloads: make(map[*TestCase]map[string]struct{}),
+	}
+}
+
+// add adds the payload sent in the request to the test case of the group.
+// The duplicate payloads are skipped.
+func (b *testCaseBuilder) add(group, payload string, req *RawRequest, typ, expect string, tags []string) {
+	key := group + "\x00" + expect + "\x00" + req.key()
+
+	tc, ok := b.index[key]
+	if !ok {
+		tc = &TestCase{
+			Name:     group,
+			Requests: []*RawRequest{req},
+			Type:     typ,
+			Expect:   expect,
+			Metadata: db.Metadata{Tags: tags},
+		}
+
+		b.index[key] = tc
+		b.payloads[tc] = make(map[string]struct{})
+		b.testCases = append(b.testCases, tc)
+	}
+
+	if _, ok = b.payloads[tc][payload]; ok {
+		return
+	}
+
+	b.payloads[tc][payload] = struct{}{}
+	tc.Payloads = append(tc.Payloads, payload)
+}
+
+// result returns the test cases. The test cases of the group are numbered if
+// there are several of them.
+func (b *testCaseBuilder) result() []*TestCase {
+	groups := make(map[string]int)
+	for _, tc := range b.testCases {
+		groups[tc.Name]++
+	}
+
+	numbers := make(map[string]int)
+	for _, tc := range b.testCases {
+		if groups[tc.Name] > 1 {
+			numbers[tc.Name]++
+			tc.Name = tc.Name + "-" + strconv.Itoa(numbers[tc.Name])
+		}
+	}
+
+	return b.testCases
+}
+
+// Write writes the test cases to the test set directory in the test cases
+// path. The test set directory must not exist.
+func Write(testCasesPath, testSet string, testCases []*TestCase) error {
+	if testSet == "" || testSet != filepath.Base(testSet) || strings.HasPrefix(testSet, ".") {
+		return errors.Errorf("bad test set name %q", testSet)
+	}
+
+	if len(testCases) == 0 {
+		return errors.New("no test cases to write")
+	}
+
+	names := make(map[string]struct{})
+	for _, tc := range testCases {
+		if _, ok := names[tc.Name]; ok {
+			return errors.Errorf("duplicate test case %s", tc.Name)
+		}
+		names[tc.Name] = struct{}{}
+	}
+
+	dir := filepath.Join(testCasesPath, testSet)
+
+	if _, err := os.Stat(dir); err == nil {
+		return errors.Errorf("test set directory %s already exists", dir)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrap(err, "couldn't create test set directory")
+	}
+
+	for _, tc := range testCases {
+		f := &testCaseFile{
+			Payloads:   tc.Payloads,
+			Encoders:   tc.Encoders,
+			Type:       tc.Type,
+			Template:   tc.Template,
+			Expect:     tc.Expect,
+			Category:   tc.Category,
+			Severity:   tc.Severity,
+			CWE:        tc.CWE,
+			OWASP:      tc.OWASP,
+			Tags:       tc.Tags,
+			References: tc.References,
+		}
+
+		if len(f.Encoders) == 0 {
+			f.Encoders = []string{"Plain"}
+		}
+
+		for _, req := range tc.Requests {
+			f.Placeholders = append(f.Placeholders, map[string]*RawRequest{"RawRequest": req})
+		}
+
+		data, err := yaml.Marshal(f)
+		if err != nil {
+			return errors.Wrapf(err, "couldn't marshal test case %s", tc.Name)
+		}
+
+		path := filepath.Join(dir, tc.Name+".yml")
+		if err = os.WriteFile(path, data, 0644); err != nil {
+			return errors.Wrap(err, "couldn't write test case")
+		}
+	}
+
+	return nil
+}
+
+// isSkippedHeader checks if the header of the imported request is skipped.
+func isSkippedHeader(header string) bool {
+	return slices.ContainsFunc(skippedHeaders, func(h string) bool {
+		return strings.EqualFold(h, header)
+	})
+}
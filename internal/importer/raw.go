@@ -0,0 +1,249 @@
+package importer
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/wallarm/gotestwaf/internal/db"
+)
+
+var (
+	// rawMarkerRegex matches the injection points marked as in Burp Intruder,
+	// e.g. §value§. The value is sent when the payload is injected into
+	// another point.
+	rawMarkerRegex = regexp.MustCompile(`§([^§]*)§`)
+	// rawVariableRegex matches the variables of the Nuclei templates
+	rawVariableRegex = regexp.MustCompile(`\{\{([^{}]*)\}\}`)
+)
+
+// nucleiTemplate is the Nuclei template with raw requests. The variables of
+// the payloads are the injection points.
+type nucleiTemplate struct {
+	HTTP     []nucleiRequest `yaml:"http"`
+	Requests []nucleiRequest `yaml:"requests"`
+}
+
+type nucleiRequest struct {
+	Raw      []string       `yaml:"raw"`
+	Payloads map[string]any `yaml:"payloads"`
+}
+
+// ImportRaw reads the raw HTTP requests, e.g. saved from Burp, and the raw
+// requests of the Nuclei templates (.yaml or .yml files) and returns the
+// RawRequest placeholder config for each injection point. The injection
+// points are marked as §value§, the variables of the payloads are also the
+// injection points in the Nuclei templates.
+func ImportRaw(paths []string) ([]*RawRequest, error) {
+	var requests []*RawRequest
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var raws []string
+
+		ext := filepath.Ext(path)
+		if ext == ".yaml" || ext == ".yml" {
+			raws, err = nucleiRawRequests(data)
+			if err != nil {
+				return nil, errors.Wrapf(err, "couldn't parse Nuclei template %s", path)
+			}
+		} else {
+			raws = []string{string(data)}
+		}
+
+		for _, raw := range raws {
+			reqs, parseErr := parseMarkedRequest(raw)
+			if parseErr != nil {
+				return nil, errors.Wrapf(parseErr, "couldn't parse request in %s", path)
+			}
+
+			requests = append(requests, reqs...)
+		}
+	}
+
+	return requests, nil
+}
+
+// WithRequests returns the test cases with the payloads, the encoders and the
+// expected outcome of the test cases sent in the requests. The test case is
+// named after the test set and the name of the original test case.
+func WithRequests(testCases []*db.Case, requests []*RawRequest) []*TestCase {
+	var result []*TestCase
+
+	for _, c := range testCases {
+		tc := &TestCase{
+			Name:     c.Set + "-" + c.Name,
+			Payloads: c.Payloads,
+			Encoders: c.Encoders,
+			Requests: requests,
+			Type:     c.Type,
+			Template: c.Template,
+			Expect:   db.ExpectBlock,
+			Category: c.Category,
+			Metadata: c.Metadata,
+		}
+
+		if !c.IsTruePositive {
+			tc.Expect = db.ExpectPass
+		}
+
+		result = append(result, tc)
+	}
+
+	return result
+}
+
+// nucleiRawRequests returns the raw requests of the Nuclei template with the
+// variables of the payloads replaced with the injection point markers.
+func nucleiRawRequests(data []byte) ([]string, error) {
+	var t nucleiTemplate
+	if err := yaml.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+
+	var raws []string
+
+	for _, r := range append(t.HTTP, t.Requests...) {
+		for _, raw := range r.Raw {
+			raw = rawVariableRegex.ReplaceAllStringFunc(raw, func(v string) string {
+				name := strings.TrimSpace(v[2 : len(v)-2])
+				if _, ok := r.Payloads[name]; ok {
+					return "§§"
+				}
+
+				return v
+			})
+
+			raws = append(raws, raw)
+		}
+	}
+
+	if len(raws) == 0 {
+		return nil, errors.New("no raw requests")
+	}
+
+	return raws, nil
+}
+
+// parseMarkedRequest returns the config for each injection point of the raw
+// request.
+func parseMarkedRequest(raw string) ([]*RawRequest, error) {
+	markers := rawMarkerRegex.FindAllStringSubmatchIndex(raw, -1)
+	if len(markers) == 0 {
+		return nil, errors.New("no injection points marked as §value§")
+	}
+
+	var requests []*RawRequest
+
+	for i := range markers {
+		var b strings.Builder
+
+		prev := 0
+		for j, m := range markers {
+			b.WriteString(raw[prev:m[0]])
+
+			if i == j {
+				b.WriteString(PayloadMarker)
+			} else {
+				b.WriteString(raw[m[2]:m[3]])
+			}
+
+			prev = m[1]
+		}
+		b.WriteString(raw[prev:])
+
+		req, err := parseRawRequest(b.String())
+		if err != nil {
+			return nil, err
+		}
+
+		requests = append(requests, req)
+	}
+
+	return requests, nil
+}
+
+// parseRawRequest parses the HTTP/1.x request. The Host and Content-Length
+// headers are skipped, they are set by the HTTP client.
+func parseRawRequest(raw string) (*RawRequest, error) {
+	head, body, found := strings.Cut(raw, "\r\n\r\n")
+	if !found {
+		head, body, _ = strings.Cut(raw, "\n\n")
+	}
+
+	lines := strings.Split(strings.Trim(head, "\r\n"), "\n")
+
+	requestLine := strings.Fields(lines[0])
+	if len(requestLine) < 2 {
+		return nil, errors.Errorf("bad request line %q", lines[0])
+	}
+
+	req := &RawRequest{
+		Method:  requestLine[0],
+		Path:    requestLine[1],
+		Headers: make(map[string]string),
+		Body:    body,
+	}
+
+	// The absolute form of the request target
+	if u, err := url.Parse(req.Path); err == nil && u.IsAbs() {
+		req.Path = strings.TrimPrefix(req.Path, u.Scheme+"://"+u.Host)
+		if req.Path == "" {
+			req.Path = "/"
+		}
+	}
+
+	for _, line := range lines[1:] {
+		line = strings.TrimRight(line, "\r")
+
+		header, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, errors.Errorf("bad header %q", line)
+		}
+
+		header = strings.TrimSpace(header)
+		if isSkippedHeader(header) {
+			continue
+		}
+
+		req.Headers[header] = strings.TrimSpace(value)
+	}
+
+	if strings.Contains(req.Method, PayloadMarker) {
+		return nil, errors.New("injection point in the method isn't supported")
+	}
+
+	parts := []string{req.Path}
+	for header, value := range req.Headers {
+		if strings.Contains(header, PayloadMarker) {
+			return nil, errors.New("injection point in the header name isn't supported")
+		}
+
+		parts = append(parts, value)
+	}
+
+	// The variables of the Nuclei templates other than the payloads aren't
+	// supported. The body isn't checked, it may contain the same syntax.
+	for _, part := range parts {
+		for _, v := range rawVariableRegex.FindAllString(part, -1) {
+			if v != PayloadMarker {
+				return nil, errors.Errorf("unsupported variable %s", v)
+			}
+		}
+	}
+
+	if !strings.Contains(strings.Join(append(parts, req.Body), "\n"), PayloadMarker) {
+		return nil, errors.New("injection point must be in the path, a header value or the body")
+	}
+
+	return req, nil
+}
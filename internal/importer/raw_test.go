@@ -0,0 +1,107 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wallarm/gotestwaf/internal/db"
+)
+
+func TestImportRaw(t *testing.T) {
+	dir := t.TempDir()
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"login.txt", "POST http://example.com/login?next=§home§ HTTP/1.1\r\n" +
+			"Host: example.com\r\n" +
+			"Content-Type: application/x-www-form-urlencoded\r\n" +
+			"Content-Length: 16\r\n" +
+			"\r\n" +
+			"user=§admin§&pass=x"},
+		{"search.yaml", `
+id: search
+http:
+  - raw:
+      - |
+        GET /search?q={{query}} HTTP/1.1
+        Host: {{Hostname}}
+        X-Request-Id: {{ query }}
+
+    payloads:
+      query: payloads.txt
+`},
+	}
+
+	var paths []string
+	for _, f := range files {
+		path := filepath.Join(dir, f.name)
+		if err := os.WriteFile(path, []byte(f.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		paths = append(paths, path)
+	}
+
+	requests, err := ImportRaw(paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []RawRequest{
+		{Method: "POST", Path: "/login?next=" + PayloadMarker, Body: "user=admin&pass=x"},
+		{Method: "POST", Path: "/login?next=home", Body: "user=" + PayloadMarker + "&pass=x"},
+		{Method: "GET", Path: "/search?q=" + PayloadMarker},
+		{Method: "GET", Path: "/search?q=", Headers: map[string]string{"X-Request-Id": PayloadMarker}},
+	}
+
+	if len(requests) != len(want) {
+		t.Fatalf("got %d requests, want %d", len(requests), len(want))
+	}
+
+	for i, req := range requests {
+		if req.Method != want[i].Method || req.Path != want[i].Path || req.Body != want[i].Body {
+			t.Errorf("got request %+v, want %+v", req, want[i])
+		}
+
+		if req.Headers["Host"] != "" || req.Headers["Content-Length"] != "" {
+			t.Errorf("got skipped headers in %+v", req.Headers)
+		}
+
+		for header, value := range want[i].Headers {
+			if req.Headers[header] != value {
+				t.Errorf("got header %s: %q, want %q", header, req.Headers[header], value)
+			}
+		}
+	}
+
+	testCases := WithRequests([]*db.Case{
+		{Set: "owasp", Name: "xss", Payloads: []string{"<script>"}, Encoders: []string{"URL"}, IsTruePositive: true},
+		{Set: "false-pos", Name: "texts", Payloads: []string{"hello"}, Encoders: []string{"Plain"}},
+	}, requests)
+
+	if len(testCases) != 2 || testCases[0].Name != "owasp-xss" || len(testCases[0].Requests) != len(want) {
+		t.Fatalf("got test cases %+v", testCases)
+	}
+	if testCases[0].Expect != db.ExpectBlock || testCases[1].Expect != db.ExpectPass {
+		t.Errorf("got expected outcomes %s and %s", testCases[0].Expect, testCases[1].Expect)
+	}
+}
+
+func TestImportRawErrors(t *testing.T) {
+	requests := []string{
+		"GET /search?q=x HTTP/1.1\r\n\r\n",
+		"§GET§ / HTTP/1.1\r\n\r\n",
+		"GET / HTTP/1.1\r\nHost: §example.com§\r\n\r\n",
+		"GET /{{BaseURL}}?q=§x§ HTTP/1.1\r\n\r\n",
+		"GET\r\n\r\n§x§",
+	}
+
+	for _, raw := range requests {
+		if _, err := parseMarkedRequest(raw); err == nil {
+			t.Errorf("no error for request %q", raw)
+		}
+	}
+}
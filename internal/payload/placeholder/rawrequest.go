@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 
 	"github.com/chromedp/chromedp"
@@ -196,9 +197,16 @@ func (r *RawRequestConfig) Hash() []byte {
 	sha256sum.Write([]byte(r.Method))
 	sha256sum.Write([]byte(r.Path))
 
-	for header, value := range r.Headers {
+	// Sort the headers to get the same hash regardless of the map order
+	headers := make([]string, 0, len(r.Headers))
+	for header := range r.Headers {
+		headers = append(headers, header)
+	}
+	sort.Strings(headers)
+
+	for _, header := range headers {
 		sha256sum.Write([]byte(header))
-		sha256sum.Write([]byte(value))
+		sha256sum.Write([]byte(r.Headers[header]))
 	}
 
 	sha256sum.Write([]byte(r.Body))
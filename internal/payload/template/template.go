@@ -0,0 +1,187 @@
+// Package template renders the payloads of the test cases with templates
+// enabled. The payload is a Go text template with the target variables,
+// e.g. {{.Host}}, the custom variables, the random generators, e.g.
+// {{rand.hex 8}}, and the helpers to build long payloads, e.g.
+// {{repeat "A" 8192}}.
+package template
+
+import (
+	"bytes"
+	"hash/fnv"
+	"math/rand"
+	"net/url"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// maxRepeatedSize limits the size of the strings built by the helpers.
+const maxRepeatedSize = 16 * 1024 * 1024
+
+const (
+	hexChars   = "0123456789abcdef"
+	alphaChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	alnumChars = alphaChars + "0123456789"
+)
+
+var (
+	// actionRegex matches the template actions
+	actionRegex = regexp.MustCompile(`(?s)\{\{.*?\}\}`)
+	// randFuncRegex matches the random generators, e.g. rand.hex, which are
+	// renamed to the valid function names, e.g. rand_hex
+	randFuncRegex = regexp.MustCompile(`\brand\.([a-z]+)\b`)
+)
+
+// Renderer renders the payload templates. The random values depend only on
+// the seed and the key passed to Render, so the same payloads are rendered
+// for the same seed regardless of the order of the tests.
+type Renderer struct {
+	vars map[string]string
+	seed int64
+}
+
+// NewRenderer creates a Renderer with the variables of the target URL and
+// the custom variables in the "<name>=<value>" format.
+func NewRenderer(targetURL string, customVars []string, seed int64) (*Renderer, error) {
+	vars, err := Vars(targetURL, customVars)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Renderer{vars: vars, seed: seed}, nil
+}
+
+// Vars returns the template variables: URL, Scheme, Host, Hostname, Port and
+// Path of the target URL, and the custom variables.
+func Vars(targetURL string, customVars []string) (map[string]string, error) {
+	u, err := url.Parse(targetURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't parse target URL")
+	}
+
+	vars := map[string]string{
+		"URL":      targetURL,
+		"Scheme":   u.Scheme,
+		"Host":     u.Host,
+		"Hostname": u.Hostname(),
+		"Port":     u.Port(),
+		"Path":     u.Path,
+	}
+
+	for _, v := range customVars {
+		name, value, ok := strings.Cut(v, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, errors.Errorf("bad template variable %q, expected <name>=<value>", v)
+		}
+
+		if _, ok = vars[name]; ok {
+			return nil, errors.Errorf("template variable %s is already defined", name)
+		}
+
+		vars[name] = value
+	}
+
+	return vars, nil
+}
+
+// Parse parses the payload template. The function arguments aren't checked.
+func Parse(payload string) (*template.Template, error) {
+	return newTemplate(payload, funcs(nil))
+}
+
+// Render renders the payload template. The key identifies the test and
+// seeds the random generators together with the renderer seed.
+func (r *Renderer) Render(payload string, key string) (string, error) {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+
+	rnd := rand.New(rand.NewSource(r.seed ^ int64(h.Sum64())))
+
+	t, err := newTemplate(payload, funcs(rnd))
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err = t.Execute(&buf, r.vars); err != nil {
+		return "", errors.Wrap(err, "couldn't render payload template")
+	}
+
+	return buf.String(), nil
+}
+
+func newTemplate(payload string, funcMap template.FuncMap) (*template.Template, error) {
+	payload = actionRegex.ReplaceAllStringFunc(payload, func(action string) string {
+		return randFuncRegex.ReplaceAllString(action, "rand_$1")
+	})
+
+	t, err := template.New("payload").
+		Option("missingkey=error").
+		Funcs(funcMap).
+		Parse(payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't parse payload template")
+	}
+
+	return t, nil
+}
+
+func funcs(rnd *rand.Rand) template.FuncMap {
+	randString := func(chars string) func(n int) (string, error) {
+		return func(n int) (string, error) {
+			if n < 0 || n > maxRepeatedSize {
+				return "", errors.Errorf("bad string length %d", n)
+			}
+
+			b := make([]byte, n)
+			for i := range b {
+				b[i] = chars[rnd.Intn(len(chars))]
+			}
+
+			return string(b), nil
+		}
+	}
+
+	return template.FuncMap{
+		"rand_hex":   randString(hexChars),
+		"rand_alpha": randString(alphaChars),
+		"rand_alnum": randString(alnumChars),
+		"rand_int": func(min, max int) (int, error) {
+			if min > max {
+				return 0, errors.Errorf("bad range [%d, %d]", min, max)
+			}
+
+			return min + rnd.Intn(max-min+1), nil
+		},
+		"rand_choice": func(items ...string) (string, error) {
+			if len(items) == 0 {
+				return "", errors.New("no items to choose from")
+			}
+
+			return items[rnd.Intn(len(items))], nil
+		},
+		"repeat": func(s string, n int) (string, error) {
+			if n < 0 || len(s)*n > maxRepeatedSize {
+				return "", errors.Errorf("bad repeat count %d", n)
+			}
+
+			return strings.Repeat(s, n), nil
+		},
+		"pad": func(size int, fill string, s string) (string, error) {
+			if size > maxRepeatedSize {
+				return "", errors.Errorf("bad padded size %d", size)
+			}
+
+			if fill == "" || len(s) >= size {
+				return s, nil
+			}
+
+			n := (size - len(s) + len(fill) - 1) / len(fill)
+
+			return (strings.Repeat(fill, n) + s)[len(fill)*n+len(s)-size:], nil
+		},
+	}
+}
@@ -0,0 +1,103 @@
+package template
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	r, err := NewRenderer("https://example.com:8443/app", []string{"table=users"}, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		payload  string
+		expected *regexp.Regexp
+	}{
+		{`<script src="//{{.Host}}/x.js">`, regexp.MustCompile(`^<script src="//example\.com:8443/x\.js">$`)},
+		{`{{.Hostname}}{{.Path}}`, regexp.MustCompile(`^example\.com/app$`)},
+		{`' union select * from {{.table}}--`, regexp.MustCompile(`^' union select \* from users--$`)},
+		{`{{rand.hex 8}}`, regexp.MustCompile(`^[0-9a-f]{8}$`)},
+		{`{{rand.int 1 100}}`, regexp.MustCompile(`^([1-9][0-9]?|100)$`)},
+		{`{{rand.choice "a" "b"}}{{rand.alpha 3}}{{rand.alnum 2}}`, regexp.MustCompile(`^[ab][a-zA-Z]{3}[a-zA-Z0-9]{2}$`)},
+		{`{{repeat "A" 1000}}`, regexp.MustCompile(`^A{1000}$`)},
+		{`{{"<script>" | pad 20 "ab"}}`, regexp.MustCompile(`^(ab){6}<script>$`)},
+		{`Math.rand.hex`, regexp.MustCompile(`^Math\.rand\.hex$`)},
+	}
+
+	for _, tt := range tests {
+		rendered, err := r.Render(tt.payload, "key")
+		if err != nil {
+			t.Errorf("couldn't render %q: %v", tt.payload, err)
+			continue
+		}
+
+		if !tt.expected.MatchString(rendered) {
+			t.Errorf("got %q for %q", rendered, tt.payload)
+		}
+	}
+
+	padded, err := r.Render(`{{"<script>" | pad 20 "ab"}}`, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(padded) != 20 || !strings.HasSuffix(padded, "<script>") {
+		t.Errorf("got padded payload %q", padded)
+	}
+}
+
+func TestRenderSeed(t *testing.T) {
+	const payload = `{{rand.hex 16}} {{rand.int 0 1000000}}`
+
+	render := func(seed int64, key string) string {
+		r, err := NewRenderer("http://example.com", nil, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rendered, err := r.Render(payload, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return rendered
+	}
+
+	if render(1, "a") != render(1, "a") {
+		t.Errorf("payload rendered with the same seed differs")
+	}
+	if render(1, "a") == render(2, "a") {
+		t.Errorf("payload rendered with different seeds is the same")
+	}
+	if render(1, "a") == render(1, "b") {
+		t.Errorf("payload rendered for different tests is the same")
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	if _, err := NewRenderer("http://example.com", []string{"Host=x"}, 0); err == nil {
+		t.Errorf("no error for the redefined variable")
+	}
+	if _, err := NewRenderer("http://example.com", []string{"novalue"}, 0); err == nil {
+		t.Errorf("no error for the variable without value")
+	}
+
+	r, err := NewRenderer("http://example.com", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, payload := range []string{`{{.Unknown}}`, `{{rand.int 5 1}}`, `{{repeat "A" -1}}`, `{{rand.hex}}`} {
+		if _, err = r.Render(payload, "key"); err == nil {
+			t.Errorf("no error for %q", payload)
+		}
+	}
+
+	for _, payload := range []string{`{{`, `{{unknown 1}}`, `{{rand.unknown 1}}`} {
+		if _, err = Parse(payload); err == nil {
+			t.Errorf("no parse error for %q", payload)
+		}
+	}
+}
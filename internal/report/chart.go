@@ -21,10 +21,10 @@ func updateCounters(t *db.TestDetails, counters map[string]map[string]pair, isBl
 	var category string
 	var typ string
 
-	if isApiTest(t.TestSet) {
-		category = "api"
+	if isApiTest(t) {
+		category = db.CategoryAPI
 	} else {
-		category = "app"
+		category = db.CategoryApp
 	}
 
 	if t.Type == "" {
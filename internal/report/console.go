@@ -64,7 +64,7 @@ func printConsoleReportTable(
 	for _, row := range s.TruePositiveTests.SummaryTable {
 		rowAppend := []string{
 			row.TestSet,
-			row.TestCase,
+			summaryTestCaseName(row),
 			fmt.Sprintf("%.2f", row.Percentage),
 			fmt.Sprintf("%d", row.Blocked),
 			fmt.Sprintf("%d", row.Bypassed),
@@ -96,10 +96,12 @@ func printConsoleReportTable(
 	}
 	if !ignoreUnresolved {
 		footerNegativeTests = append(footerNegativeTests,
-			fmt.Sprintf("Unresolved (Sent):\n%d/%d (%.2f%%)",
+			fmt.Sprintf("Unresolved (Sent):\n%d/%d (%.2f%%)%s%s",
 				s.TruePositiveTests.ReqStats.UnresolvedRequestsNumber,
 				s.TruePositiveTests.ReqStats.AllRequestsNumber,
 				s.TruePositiveTests.UnresolvedRequestsPercentage,
+				appRejectedNote(s.TruePositiveTests.AppRejectedRequestsNumber),
+				tarpittedNote(s.TruePositiveTests.TarpittedRequestsNumber),
 			),
 		)
 	}
@@ -124,7 +126,7 @@ func printConsoleReportTable(
 	for _, row := range s.TrueNegativeTests.SummaryTable {
 		rowAppend := []string{
 			row.TestSet,
-			row.TestCase,
+			summaryTestCaseName(row),
 			fmt.Sprintf("%.2f", row.Percentage),
 			fmt.Sprintf("%d", row.Blocked),
 			fmt.Sprintf("%d", row.Bypassed),
@@ -156,10 +158,12 @@ func printConsoleReportTable(
 	}
 	if !ignoreUnresolved {
 		footerPositiveTests = append(footerPositiveTests,
-			fmt.Sprintf("Unresolved (Sent):\n%d/%d (%.2f%%)",
+			fmt.Sprintf("Unresolved (Sent):\n%d/%d (%.2f%%)%s%s",
 				s.TrueNegativeTests.ReqStats.UnresolvedRequestsNumber,
 				s.TrueNegativeTests.ReqStats.AllRequestsNumber,
 				s.TrueNegativeTests.UnresolvedRequestsPercentage,
+				appRejectedNote(s.TrueNegativeTests.AppRejectedRequestsNumber),
+				tarpittedNote(s.TrueNegativeTests.TarpittedRequestsNumber),
 			),
 		)
 	}
@@ -257,6 +261,9 @@ func printConsoleReportJson(
 				BypassedTests:   s.TruePositiveTests.ReqStats.BypassedRequestsNumber,
 				UnresolvedTests: s.TruePositiveTests.ReqStats.UnresolvedRequestsNumber,
 				FailedTests:     s.TruePositiveTests.ReqStats.FailedRequestsNumber,
+
+				AppRejectedTests: s.TruePositiveTests.AppRejectedRequestsNumber,
+				TarpittedTests:   s.TruePositiveTests.TarpittedRequestsNumber,
 			},
 			ApiSecStat: requestStats{
 				TotalSent:       s.TruePositiveTests.ApiSecReqStats.AllRequestsNumber,
@@ -274,7 +281,9 @@ func printConsoleReportJson(
 				UnresolvedTests: s.TruePositiveTests.AppSecReqStats.UnresolvedRequestsNumber,
 				FailedTests:     s.TruePositiveTests.AppSecReqStats.FailedRequestsNumber,
 			},
-			TestSets: make(testSets),
+			TestSets:     make(testSets),
+			BlockedBy:    s.TruePositiveTests.BlockedByWAF,
+			MatchedRules: s.TruePositiveTests.MatchedRules,
 		}
 		for _, row := range s.TruePositiveTests.SummaryTable {
 			if report.TruePositiveTests.TestSets[row.TestSet] == nil {
@@ -287,6 +296,7 @@ func printConsoleReportJson(
 				Bypassed:   row.Bypassed,
 				Unresolved: row.Unresolved,
 				Failed:     row.Failed,
+				Metadata:   row.Metadata,
 			}
 		}
 	}
@@ -301,6 +311,9 @@ func printConsoleReportJson(
 				BypassedTests:   s.TrueNegativeTests.ReqStats.BypassedRequestsNumber,
 				UnresolvedTests: s.TrueNegativeTests.ReqStats.UnresolvedRequestsNumber,
 				FailedTests:     s.TrueNegativeTests.ReqStats.FailedRequestsNumber,
+
+				AppRejectedTests: s.TrueNegativeTests.AppRejectedRequestsNumber,
+				TarpittedTests:   s.TrueNegativeTests.TarpittedRequestsNumber,
 			},
 			ApiSecStat: requestStats{
 				TotalSent:       s.TrueNegativeTests.ApiSecReqStats.AllRequestsNumber,
@@ -318,7 +331,9 @@ func printConsoleReportJson(
 				UnresolvedTests: s.TrueNegativeTests.AppSecReqStats.UnresolvedRequestsNumber,
 				FailedTests:     s.TrueNegativeTests.AppSecReqStats.FailedRequestsNumber,
 			},
-			TestSets: make(testSets),
+			TestSets:     make(testSets),
+			BlockedBy:    s.TrueNegativeTests.BlockedByWAF,
+			MatchedRules: s.TrueNegativeTests.MatchedRules,
 		}
 		for _, row := range s.TrueNegativeTests.SummaryTable {
 			if report.TrueNegativeTests.TestSets[row.TestSet] == nil {
@@ -331,6 +346,7 @@ func printConsoleReportJson(
 				Bypassed:   row.Bypassed,
 				Unresolved: row.Unresolved,
 				Failed:     row.Failed,
+				Metadata:   row.Metadata,
 			}
 		}
 	}
@@ -344,3 +360,23 @@ func printConsoleReportJson(
 
 	return nil
 }
+
+// appRejectedNote returns the number of unresolved requests rejected by the
+// application for the table footer.
+func appRejectedNote(appRejected int) string {
+	if appRejected == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("\nApp rejected: %d", appRejected)
+}
+
+// tarpittedNote returns the number of unresolved requests which were delayed
+// or timed out for the table footer.
+func tarpittedNote(tarpitted int) string {
+	if tarpitted == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("\nTarpitted: %d", tarpitted)
+}
@@ -0,0 +1,96 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+
+	"github.com/wallarm/gotestwaf/internal/scanner"
+)
+
+// dryRunJsonReport is the dry run report in json format, the estimated
+// duration is printed as a string.
+type dryRunJsonReport struct {
+	*scanner.DryRunStats
+
+	EstimatedDuration string `json:"estimated_duration"`
+}
+
+// RenderDryRunReport prints the statistics of the dry run in selected format.
+func RenderDryRunReport(s *scanner.DryRunStats, format string) error {
+	switch format {
+	case consoleReportTextFormat:
+		printDryRunReportTable(s)
+	case consoleReportJsonFormat:
+		report := &dryRunJsonReport{
+			DryRunStats:       s,
+			EstimatedDuration: s.EstimatedDuration.String(),
+		}
+
+		jsonBytes, err := json.Marshal(report)
+		if err != nil {
+			return errors.Wrap(err, "couldn't dump dry run report")
+		}
+
+		fmt.Println(string(jsonBytes))
+	default:
+		return fmt.Errorf("unknown report format: %s", format)
+	}
+
+	return nil
+}
+
+// printDryRunReportTable prints the statistics of the dry run in tabular
+// format.
+func printDryRunReportTable(s *scanner.DryRunStats) {
+	var buffer strings.Builder
+
+	for _, group := range []struct {
+		title  string
+		column string
+		counts map[string]*scanner.DryRunCount
+	}{
+		{"Requests by test set", "Test set", s.Sets},
+		{"Requests by test case", "Test case", s.Cases},
+		{"Requests by placeholder", "Placeholder", s.Placeholders},
+		{"Requests by encoder", "Encoder", s.Encoders},
+	} {
+		fmt.Fprintf(&buffer, "%s:\n", group.title)
+
+		table := tablewriter.NewWriter(&buffer)
+		table.Header([]string{group.column, "Requests", "Bytes"})
+
+		names := make([]string, 0, len(group.counts))
+		for name := range group.counts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			c := group.counts[name]
+			table.Append([]string{name, fmt.Sprintf("%d", c.Requests), fmt.Sprintf("%d", c.Bytes)})
+		}
+
+		table.Render()
+		fmt.Fprintln(&buffer)
+	}
+
+	fmt.Fprintf(&buffer, "Summary:\n")
+
+	sumTable := tablewriter.NewWriter(&buffer)
+	sumTable.Header([]string{"Tests", "Requests", "Bytes", "Failed", "Estimated duration"})
+	sumTable.Append([]string{
+		fmt.Sprintf("%d", s.Tests),
+		fmt.Sprintf("%d", s.Requests),
+		fmt.Sprintf("%d", s.Bytes),
+		fmt.Sprintf("%d", s.Failed),
+		s.EstimatedDuration.String(),
+	})
+	sumTable.Render()
+
+	fmt.Println(buffer.String())
+}
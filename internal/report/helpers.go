@@ -1,8 +1,28 @@
 package report
 
-import "strings"
+import (
+	"fmt"
+	"strings"
 
-// isApiTest checks if a set of tests has the API category.
-func isApiTest(setName string) bool {
-	return strings.Contains(setName, "api")
+	"github.com/wallarm/gotestwaf/internal/db"
+)
+
+// isApiTest checks if the test has the API category. The category of the
+// test without it is inferred from the test set name.
+func isApiTest(t *db.TestDetails) bool {
+	if t.Category != "" {
+		return t.Category == db.CategoryAPI
+	}
+
+	return strings.Contains(t.TestSet, "api")
+}
+
+// summaryTestCaseName returns the name of the test case with its severity
+// for the summary table.
+func summaryTestCaseName(row *db.SummaryTableRow) string {
+	if row.Severity == "" {
+		return row.TestCase
+	}
+
+	return fmt.Sprintf("%s (%s)", row.TestCase, row.Severity)
 }
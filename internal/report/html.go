@@ -3,6 +3,7 @@ package report
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -291,8 +292,13 @@ func prepareHTMLFullReport(
 			}
 
 			negBypassed[paths][payload][d.ResponseStatusCode].TestCase = d.TestCase
+			negBypassed[paths][payload][d.ResponseStatusCode].Metadata = d.Metadata
 			negBypassed[paths][payload][d.ResponseStatusCode].Encoders[d.Encoder] = nil
 			negBypassed[paths][payload][d.ResponseStatusCode].Placeholders[d.Placeholder] = nil
+
+			if negBypassed[paths][payload][d.ResponseStatusCode].Evidence == nil {
+				negBypassed[paths][payload][d.ResponseStatusCode].Evidence = d.Evidence
+			}
 		}
 
 		// map[payload]map[statusCode]*testDetails
@@ -309,12 +315,26 @@ func prepareHTMLFullReport(
 				negUnresolved[payload][d.ResponseStatusCode] = &report.TestDetails{
 					Encoders:     make(map[string]any),
 					Placeholders: make(map[string]any),
+					BlockedBy:    make(map[string]any),
 				}
 			}
 
 			negUnresolved[payload][d.ResponseStatusCode].TestCase = d.TestCase
+			negUnresolved[payload][d.ResponseStatusCode].Metadata = d.Metadata
 			negUnresolved[payload][d.ResponseStatusCode].Encoders[d.Encoder] = nil
 			negUnresolved[payload][d.ResponseStatusCode].Placeholders[d.Placeholder] = nil
+
+			if negUnresolved[payload][d.ResponseStatusCode].Evidence == nil {
+				negUnresolved[payload][d.ResponseStatusCode].Evidence = d.Evidence
+			}
+
+			for _, name := range d.BlockedBy {
+				negUnresolved[payload][d.ResponseStatusCode].BlockedBy[name] = nil
+			}
+
+			if d.AppRejected {
+				negUnresolved[payload][d.ResponseStatusCode].AppRejected = true
+			}
 		}
 
 		data.TruePositiveTests.Bypassed = negBypassed
@@ -335,12 +355,22 @@ func prepareHTMLFullReport(
 				posBlocked[payload][d.ResponseStatusCode] = &report.TestDetails{
 					Encoders:     make(map[string]any),
 					Placeholders: make(map[string]any),
+					BlockedBy:    make(map[string]any),
 				}
 			}
 
 			posBlocked[payload][d.ResponseStatusCode].TestCase = d.TestCase
+			posBlocked[payload][d.ResponseStatusCode].Metadata = d.Metadata
 			posBlocked[payload][d.ResponseStatusCode].Encoders[d.Encoder] = nil
 			posBlocked[payload][d.ResponseStatusCode].Placeholders[d.Placeholder] = nil
+
+			if posBlocked[payload][d.ResponseStatusCode].Evidence == nil {
+				posBlocked[payload][d.ResponseStatusCode].Evidence = d.Evidence
+			}
+
+			for _, name := range d.BlockedBy {
+				posBlocked[payload][d.ResponseStatusCode].BlockedBy[name] = nil
+			}
 		}
 
 		// map[payload]map[statusCode]*testDetails
@@ -361,8 +391,13 @@ func prepareHTMLFullReport(
 			}
 
 			posBypassed[payload][d.ResponseStatusCode].TestCase = d.TestCase
+			posBypassed[payload][d.ResponseStatusCode].Metadata = d.Metadata
 			posBypassed[payload][d.ResponseStatusCode].Encoders[d.Encoder] = nil
 			posBypassed[payload][d.ResponseStatusCode].Placeholders[d.Placeholder] = nil
+
+			if posBypassed[payload][d.ResponseStatusCode].Evidence == nil {
+				posBypassed[payload][d.ResponseStatusCode].Evidence = d.Evidence
+			}
 		}
 
 		// map[payload]map[statusCode]*testDetails
@@ -379,12 +414,26 @@ func prepareHTMLFullReport(
 				posUnresolved[payload][d.ResponseStatusCode] = &report.TestDetails{
 					Encoders:     make(map[string]any),
 					Placeholders: make(map[string]any),
+					BlockedBy:    make(map[string]any),
 				}
 			}
 
 			posUnresolved[payload][d.ResponseStatusCode].TestCase = d.TestCase
+			posUnresolved[payload][d.ResponseStatusCode].Metadata = d.Metadata
 			posUnresolved[payload][d.ResponseStatusCode].Encoders[d.Encoder] = nil
 			posUnresolved[payload][d.ResponseStatusCode].Placeholders[d.Placeholder] = nil
+
+			if posUnresolved[payload][d.ResponseStatusCode].Evidence == nil {
+				posUnresolved[payload][d.ResponseStatusCode].Evidence = d.Evidence
+			}
+
+			for _, name := range d.BlockedBy {
+				posUnresolved[payload][d.ResponseStatusCode].BlockedBy[name] = nil
+			}
+
+			if d.AppRejected {
+				posUnresolved[payload][d.ResponseStatusCode].AppRejected = true
+			}
 		}
 
 		data.TrueNegativeTests.Blocked = posBlocked
@@ -395,11 +444,26 @@ func prepareHTMLFullReport(
 
 	data.ScannedPaths = s.Paths
 
+	for testSet, l := range s.Latency {
+		data.LatencyTable = append(data.LatencyTable, &report.LatencyTableRow{
+			TestSet:  testSet,
+			Requests: l.Requests,
+			Min:      l.Min.Milliseconds(),
+			Median:   l.Median.Milliseconds(),
+			P90:      l.P90.Milliseconds(),
+			Max:      l.Max.Milliseconds(),
+		})
+	}
+	sort.Slice(data.LatencyTable, func(i, j int) bool {
+		return data.LatencyTable[i].TestSet < data.LatencyTable[j].TestSet
+	})
+
 	data.TruePositiveTests.Percentage = s.TruePositiveTests.ResolvedBlockedRequestsPercentage
 	data.TruePositiveTests.TotalSent = s.TruePositiveTests.ReqStats.AllRequestsNumber
 	data.TruePositiveTests.BlockedRequestsNumber = s.TruePositiveTests.ReqStats.BlockedRequestsNumber
 	data.TruePositiveTests.BypassedRequestsNumber = s.TruePositiveTests.ReqStats.BypassedRequestsNumber
 	data.TruePositiveTests.UnresolvedRequestsNumber = s.TruePositiveTests.ReqStats.UnresolvedRequestsNumber
+	data.TruePositiveTests.AppRejectedRequestsNumber = s.TruePositiveTests.AppRejectedRequestsNumber
 	data.TruePositiveTests.FailedRequestsNumber = s.TruePositiveTests.ReqStats.FailedRequestsNumber
 
 	data.TrueNegativeTests.Percentage = s.TrueNegativeTests.ResolvedBypassedRequestsPercentage
@@ -407,12 +471,14 @@ func prepareHTMLFullReport(
 	data.TrueNegativeTests.BlockedRequestsNumber = s.TrueNegativeTests.ReqStats.BlockedRequestsNumber
 	data.TrueNegativeTests.BypassedRequestsNumber = s.TrueNegativeTests.ReqStats.BypassedRequestsNumber
 	data.TrueNegativeTests.UnresolvedRequestsNumber = s.TrueNegativeTests.ReqStats.UnresolvedRequestsNumber
+	data.TrueNegativeTests.AppRejectedRequestsNumber = s.TrueNegativeTests.AppRejectedRequestsNumber
 	data.TrueNegativeTests.FailedRequestsNumber = s.TrueNegativeTests.ReqStats.FailedRequestsNumber
 
 	data.TotalSent = data.TruePositiveTests.TotalSent + data.TrueNegativeTests.TotalSent
 	data.BlockedRequestsNumber = data.TruePositiveTests.BlockedRequestsNumber + data.TrueNegativeTests.BlockedRequestsNumber
 	data.BypassedRequestsNumber = data.TruePositiveTests.BypassedRequestsNumber + data.TrueNegativeTests.BypassedRequestsNumber
 	data.UnresolvedRequestsNumber = data.TruePositiveTests.UnresolvedRequestsNumber + data.TrueNegativeTests.UnresolvedRequestsNumber
+	data.AppRejectedRequestsNumber = data.TruePositiveTests.AppRejectedRequestsNumber + data.TrueNegativeTests.AppRejectedRequestsNumber
 	data.FailedRequestsNumber = data.TruePositiveTests.FailedRequestsNumber + data.TrueNegativeTests.FailedRequestsNumber
 
 	return data, nil
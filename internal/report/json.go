@@ -38,6 +38,11 @@ type testsInfo struct {
 	AppSecStat requestStats `json:"app_sec"`
 
 	TestSets testSets `json:"test_sets"`
+
+	// BlockedBy contains the number of blocked requests by the identified WAF
+	BlockedBy map[string]int `json:"blocked_by,omitempty"`
+	// MatchedRules contains the number of tests classified by each response rule
+	MatchedRules map[string]int `json:"matched_rules,omitempty"`
 }
 
 type requestStats struct {
@@ -47,6 +52,13 @@ type requestStats struct {
 	BypassedTests   int `json:"bypassed_tests"`
 	UnresolvedTests int `json:"unresolved_tests"`
 	FailedTests     int `json:"failed_tests"`
+
+	// AppRejectedTests is the number of unresolved tests rejected by the
+	// application, it is set only for the summary
+	AppRejectedTests int `json:"app_rejected_tests,omitempty"`
+	// TarpittedTests is the number of unresolved tests which were delayed or
+	// timed out, it is set only for the summary
+	TarpittedTests int `json:"tarpitted_tests,omitempty"`
 }
 
 type testSets map[string]testCases
@@ -60,6 +72,8 @@ type testCaseInfo struct {
 	Bypassed   int     `json:"bypassed"`
 	Unresolved int     `json:"unresolved"`
 	Failed     int     `json:"failed"`
+
+	db.Metadata
 }
 
 type summary struct {
@@ -83,11 +97,26 @@ type payloadDetails struct {
 	Status      int    `json:"status,omitempty"`
 	TestResult  string `json:"test_result"`
 
+	// Confidence of the block decision if the responses were calibrated
+	Confidence float64 `json:"confidence,omitempty"`
+	// Names of the identified WAFs whose signatures matched the response
+	BlockedBy []string `json:"blocked_by,omitempty"`
+	// Name of the response rule which classified the response
+	Rule string `json:"rule,omitempty"`
+	// Response time in milliseconds
+	LatencyMs int64 `json:"latency_ms,omitempty"`
+	// Whether the response was delayed compared to the benign requests
+	Tarpitted bool `json:"tarpitted,omitempty"`
+	// Response headers and body excerpt if the evidence was saved
+	Evidence *db.Evidence `json:"evidence,omitempty"`
+
 	// Used for non-failed payloads
 	AdditionalInformation []string `json:"additional_info,omitempty"`
 
 	// Used for failed payloads
 	Reason []string `json:"reason,omitempty"`
+
+	db.Metadata
 }
 
 // printFullReportToJson prepares and prints a full report in JSON format to the file.
@@ -116,6 +145,9 @@ func printFullReportToJson(
 				BypassedTests:   s.TruePositiveTests.ReqStats.BypassedRequestsNumber,
 				UnresolvedTests: s.TruePositiveTests.ReqStats.UnresolvedRequestsNumber,
 				FailedTests:     s.TruePositiveTests.ReqStats.FailedRequestsNumber,
+
+				AppRejectedTests: s.TruePositiveTests.AppRejectedRequestsNumber,
+				TarpittedTests:   s.TruePositiveTests.TarpittedRequestsNumber,
 			},
 			ApiSecStat: requestStats{
 				TotalSent:       s.TruePositiveTests.ApiSecReqStats.AllRequestsNumber,
@@ -133,7 +165,9 @@ func printFullReportToJson(
 				UnresolvedTests: s.TruePositiveTests.AppSecReqStats.UnresolvedRequestsNumber,
 				FailedTests:     s.TruePositiveTests.AppSecReqStats.FailedRequestsNumber,
 			},
-			TestSets: make(testSets),
+			TestSets:     make(testSets),
+			BlockedBy:    s.TruePositiveTests.BlockedByWAF,
+			MatchedRules: s.TruePositiveTests.MatchedRules,
 		}
 		for _, row := range s.TruePositiveTests.SummaryTable {
 			if report.Summary.TruePositiveTests.TestSets[row.TestSet] == nil {
@@ -146,6 +180,7 @@ func printFullReportToJson(
 				Bypassed:   row.Bypassed,
 				Unresolved: row.Unresolved,
 				Failed:     row.Failed,
+				Metadata:   row.Metadata,
 			}
 		}
 	}
@@ -160,6 +195,9 @@ func printFullReportToJson(
 				BypassedTests:   s.TrueNegativeTests.ReqStats.BypassedRequestsNumber,
 				UnresolvedTests: s.TrueNegativeTests.ReqStats.UnresolvedRequestsNumber,
 				FailedTests:     s.TrueNegativeTests.ReqStats.FailedRequestsNumber,
+
+				AppRejectedTests: s.TrueNegativeTests.AppRejectedRequestsNumber,
+				TarpittedTests:   s.TrueNegativeTests.TarpittedRequestsNumber,
 			},
 			ApiSecStat: requestStats{
 				TotalSent:       s.TrueNegativeTests.ApiSecReqStats.AllRequestsNumber,
@@ -177,7 +215,9 @@ func printFullReportToJson(
 				UnresolvedTests: s.TrueNegativeTests.AppSecReqStats.UnresolvedRequestsNumber,
 				FailedTests:     s.TrueNegativeTests.AppSecReqStats.FailedRequestsNumber,
 			},
-			TestSets: make(testSets),
+			TestSets:     make(testSets),
+			BlockedBy:    s.TrueNegativeTests.BlockedByWAF,
+			MatchedRules: s.TrueNegativeTests.MatchedRules,
 		}
 		for _, row := range s.TrueNegativeTests.SummaryTable {
 			if report.Summary.TrueNegativeTests.TestSets[row.TestSet] == nil {
@@ -190,6 +230,7 @@ func printFullReportToJson(
 				Bypassed:   row.Bypassed,
 				Unresolved: row.Unresolved,
 				Failed:     row.Failed,
+				Metadata:   row.Metadata,
 			}
 		}
 	}
@@ -204,6 +245,12 @@ func printFullReportToJson(
 			Encoder:               bypass.Encoder,
 			Placeholder:           bypass.Encoder,
 			Status:                bypass.ResponseStatusCode,
+			Confidence:            bypass.Confidence,
+			Rule:                  bypass.MatchedRule,
+			LatencyMs:             bypass.Latency.Milliseconds(),
+			Tarpitted:             bypass.Tarpitted,
+			Evidence:              bypass.Evidence,
+			Metadata:              bypass.Metadata,
 			TestResult:            "failed",
 			AdditionalInformation: bypass.AdditionalInfo,
 		}
@@ -219,7 +266,13 @@ func printFullReportToJson(
 				Encoder:               unresolved.Encoder,
 				Placeholder:           unresolved.Encoder,
 				Status:                unresolved.ResponseStatusCode,
-				TestResult:            "unknown",
+				Confidence:            unresolved.Confidence,
+				Rule:                  unresolved.MatchedRule,
+				LatencyMs:             unresolved.Latency.Milliseconds(),
+				Tarpitted:             unresolved.Tarpitted,
+				Evidence:              unresolved.Evidence,
+				Metadata:              unresolved.Metadata,
+				TestResult:            unresolvedTestResult(unresolved),
 				AdditionalInformation: unresolved.AdditionalInfo,
 			}
 
@@ -234,6 +287,7 @@ func printFullReportToJson(
 			Encoder:     failed.Encoder,
 			Placeholder: failed.Encoder,
 			Reason:      failed.Reason,
+			Metadata:    failed.Metadata,
 		}
 
 		report.TruePositiveTestsPayloads.Failed = append(report.TruePositiveTestsPayloads.Failed, failedDetail)
@@ -249,6 +303,13 @@ func printFullReportToJson(
 			Encoder:               blocked.Encoder,
 			Placeholder:           blocked.Encoder,
 			Status:                blocked.ResponseStatusCode,
+			Confidence:            blocked.Confidence,
+			Rule:                  blocked.MatchedRule,
+			LatencyMs:             blocked.Latency.Milliseconds(),
+			Tarpitted:             blocked.Tarpitted,
+			Evidence:              blocked.Evidence,
+			Metadata:              blocked.Metadata,
+			BlockedBy:             blocked.BlockedBy,
 			TestResult:            "failed",
 			AdditionalInformation: blocked.AdditionalInfo,
 		}
@@ -264,7 +325,13 @@ func printFullReportToJson(
 				Encoder:               unresolved.Encoder,
 				Placeholder:           unresolved.Encoder,
 				Status:                unresolved.ResponseStatusCode,
-				TestResult:            "unknown",
+				Confidence:            unresolved.Confidence,
+				Rule:                  unresolved.MatchedRule,
+				LatencyMs:             unresolved.Latency.Milliseconds(),
+				Tarpitted:             unresolved.Tarpitted,
+				Evidence:              unresolved.Evidence,
+				Metadata:              unresolved.Metadata,
+				TestResult:            unresolvedTestResult(unresolved),
 				AdditionalInformation: unresolved.AdditionalInfo,
 			}
 
@@ -279,6 +346,7 @@ func printFullReportToJson(
 			Encoder:     failed.Encoder,
 			Placeholder: failed.Encoder,
 			Reason:      failed.Reason,
+			Metadata:    failed.Metadata,
 		}
 
 		report.TrueNegativeTestsPayloads.Failed = append(report.TrueNegativeTestsPayloads.Failed, failedDetail)
@@ -302,3 +370,17 @@ func printFullReportToJson(
 
 	return nil
 }
+
+// unresolvedTestResult returns the result of the unresolved test for the
+// JSON report.
+func unresolvedTestResult(t *db.TestDetails) string {
+	if t.AppRejected {
+		return "app_rejected"
+	}
+
+	if t.Tarpitted {
+		return "tarpitted"
+	}
+
+	return "unknown"
+}
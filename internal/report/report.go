@@ -25,6 +25,7 @@ const (
 	JsonFormat = "json"
 	HtmlFormat = "html"
 	PdfFormat  = "pdf"
+	HarFormat  = "har"
 )
 
 var (
@@ -33,6 +34,7 @@ var (
 		JsonFormat: nil,
 		HtmlFormat: nil,
 		PdfFormat:  nil,
+		HarFormat:  nil,
 	}
 	ReportFormats = slices.Collect(maps.Keys(ReportFormatsSet))
 )
@@ -55,6 +57,7 @@ func SendReportByEmail(
 }
 
 // ExportFullReport saves full report on disk in different formats: HTML, PDF, JSON.
+// The HAR file is written by the scanner during the scan.
 func ExportFullReport(
 	ctx context.Context, s *db.Statistics, reportFile string, reportTime time.Time,
 	wafName string, url string, openApiFile string, args []string, ignoreUnresolved bool,
@@ -88,6 +91,9 @@ func ExportFullReport(
 				return nil, err
 			}
 
+		case HarFormat:
+			continue
+
 		case NoneFormat:
 			return nil, nil
 
@@ -145,6 +151,10 @@ func IsNoneReportFormat(reportFormat []string) bool {
 	return false
 }
 
+func IsHarReportFormat(reportFormats []string) bool {
+	return slices.Contains(reportFormats, HarFormat)
+}
+
 func IsPdfOrHtmlReportFormat(reportFormats []string) bool {
 	for _, format := range reportFormats {
 		if format == PdfFormat {
@@ -0,0 +1,162 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/wallarm/gotestwaf/internal/db"
+	p "github.com/wallarm/gotestwaf/internal/payload"
+	"github.com/wallarm/gotestwaf/internal/payload/placeholder"
+	"github.com/wallarm/gotestwaf/internal/scanner/types"
+)
+
+// baseline is the application response to a benign payload sent through a
+// placeholder or an OpenAPI request template.
+type baseline struct {
+	fingerprint *fingerprint
+
+	// rejected is true if the application rejected the request shape, e.g.
+	// responded with 415 to an XML body on a JSON-only endpoint
+	rejected bool
+}
+
+// matches checks if the response is similar to the baseline response.
+func (b *baseline) matches(resp types.Response) bool {
+	return newFingerprint(resp).similarity(b.fingerprint) >= similarityThreshold
+}
+
+// baselineKey returns the key of the baseline for the placeholder. The
+// OpenAPI request templates are identified by the additional info, which
+// contains the method and the path of the template.
+func baselineKey(ph *db.Placeholder, additionalInfo string) string {
+	if additionalInfo != "" {
+		return fmt.Sprintf("%s %s", ph.Name, additionalInfo)
+	}
+
+	return placeholderKey(ph)
+}
+
+// getBaseline returns the baseline for the placeholder or nil if there is
+// no baseline.
+func (s *Scanner) getBaseline(ph *db.Placeholder, additionalInfo string) *baseline {
+	if s.baselines == nil || ph == nil {
+		return nil
+	}
+
+	return s.baselines[baselineKey(ph, additionalInfo)]
+}
+
+// CollectBaselines sends a benign payload through each placeholder used by
+// the test cases and each OpenAPI request template, and records the
+// responses. Test responses are compared with them to distinguish requests
+// rejected by the application from requests blocked by the WAF.
+func (s *Scanner) CollectBaselines(ctx context.Context) error {
+	s.logger.WithField("status", "started").Info("Collecting baseline responses")
+
+	baselines := make(map[string]*baseline)
+
+	for _, testCase := range s.db.GetTestCases() {
+		for _, ph := range testCase.Placeholders {
+			if ph.Name == placeholder.DefaultGRPC.GetName() || ph.Name == placeholder.DefaultGraphQL.GetName() {
+				continue
+			}
+
+			if s.requestTemplates != nil {
+				if err := s.collectOpenAPIBaselines(ctx, ph, baselines); err != nil {
+					return err
+				}
+			}
+
+			key := baselineKey(ph, "")
+			if _, ok := baselines[key]; ok {
+				continue
+			}
+
+			pl := &p.PayloadInfo{
+				Payload:           randomWord(calibrationPayloadLength),
+				EncoderName:       "URL",
+				PlaceholderName:   ph.Name,
+				PlaceholderConfig: ph.Config,
+			}
+
+			resp, err := s.httpClient.SendPayload(ctx, s.cfg.URL, pl)
+			if err != nil {
+				return errors.Wrapf(err, "couldn't send benign request with placeholder %s", ph.Name)
+			}
+
+			baselines[key] = s.newBaseline(ph, resp)
+		}
+	}
+
+	s.baselines = baselines
+
+	var rejected int
+	for _, b := range baselines {
+		if b.rejected {
+			rejected++
+		}
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"status":    "done",
+		"baselines": len(baselines),
+		"rejected":  rejected,
+	}).Info("Collecting baseline responses")
+
+	return nil
+}
+
+// collectOpenAPIBaselines records baselines for the OpenAPI request
+// templates that support the placeholder.
+func (s *Scanner) collectOpenAPIBaselines(
+	ctx context.Context,
+	ph *db.Placeholder,
+	baselines map[string]*baseline,
+) error {
+	for _, template := range s.requestTemplates[ph.Name] {
+		key := baselineKey(ph, fmt.Sprintf("%s %s", template.Method, template.Path))
+		if _, ok := baselines[key]; ok {
+			continue
+		}
+
+		r, err := template.CreateRequest(ctx, ph.Name, randomWord(calibrationPayloadLength))
+		if err != nil {
+			return errors.Wrap(err, "create request from template")
+		}
+		if r == nil {
+			continue
+		}
+
+		resp, err := s.httpClient.SendRequest(ctx, &types.GoHTTPRequest{Req: r})
+		if err != nil {
+			return errors.Wrapf(err, "couldn't send benign request to %s %s", template.Method, template.Path)
+		}
+
+		baselines[key] = s.newBaseline(ph, resp)
+	}
+
+	return nil
+}
+
+func (s *Scanner) newBaseline(ph *db.Placeholder, resp types.Response) *baseline {
+	b := &baseline{fingerprint: newFingerprint(resp)}
+
+	// An error response to a benign request which doesn't look like the block
+	// page means that the application doesn't accept the request
+	if resp.GetStatusCode() >= 400 {
+		blocked, _, _, _, err := s.checkBlockedOrPassed(ph, resp)
+		b.rejected = err == nil && !blocked
+	}
+
+	if b.rejected {
+		s.logger.WithFields(logrus.Fields{
+			"placeholder": ph.Name,
+			"status":      resp.GetStatusCode(),
+		}).Debug("The application rejected the benign request")
+	}
+
+	return b
+}
@@ -0,0 +1,121 @@
+package scanner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/wallarm/gotestwaf/internal/config"
+	"github.com/wallarm/gotestwaf/internal/db"
+	"github.com/wallarm/gotestwaf/internal/scanner/waf_detector/detectors"
+)
+
+func TestBaseline(t *testing.T) {
+	s := &Scanner{
+		logger: logrus.New(),
+		cfg: &config.Config{
+			BlockStatusCodes: []int{403},
+			PassStatusCodes:  []int{200, 404},
+		},
+	}
+	ph := &db.Placeholder{Name: "XMLBody"}
+
+	const unsupported = `{"error": "unsupported media type", "expected": "application/json"}`
+
+	rejected := s.newBaseline(ph, newTestResponse(415, unsupported))
+	if !rejected.rejected {
+		t.Errorf("415 response to a benign request isn't marked as rejected")
+	}
+	if !rejected.matches(newTestResponse(415, unsupported)) {
+		t.Errorf("the same response doesn't match the baseline")
+	}
+	if rejected.matches(newTestResponse(403, "<html><title>Access denied</title></html>")) {
+		t.Errorf("the block page matches the baseline")
+	}
+
+	blocked := s.newBaseline(ph, newTestResponse(403, "Forbidden"))
+	if blocked.rejected {
+		t.Errorf("blocked benign request is marked as rejected by the application")
+	}
+
+	accepted := s.newBaseline(ph, newTestResponse(200, "OK"))
+	if accepted.rejected {
+		t.Errorf("200 response is marked as rejected")
+	}
+}
+
+func TestBaselineKeepsDeclaredBlock(t *testing.T) {
+	const rejectedPage = "<html><title>Request rejected</title></html>"
+
+	rules, err := compileRules([]*config.ResponseRule{{
+		Name:   "rejected-page",
+		Action: "block",
+		Match:  &config.RuleCondition{Body: "Request rejected"},
+	}})
+	if err != nil {
+		t.Fatalf("couldn't compile rules: %v", err)
+	}
+
+	ph := &db.Placeholder{Name: "XMLBody"}
+
+	testCases := []struct {
+		name      string
+		rules     []*rule
+		signature detectors.Check
+	}{
+		{name: "rule", rules: rules},
+		{name: "signature", signature: detectors.CheckContent("Request rejected", true)},
+	}
+
+	for _, tc := range testCases {
+		testsDB, err := db.NewDB([]*db.Case{{
+			Payloads:     []string{"<script>"},
+			Encoders:     []string{"Plain"},
+			Placeholders: []*db.Placeholder{ph},
+			Set:          "owasp",
+			Name:         "xss",
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		s := &Scanner{
+			logger: logrus.New(),
+			db:     testsDB,
+			rules:  tc.rules,
+			cfg: &config.Config{
+				BlockStatusCodes: []int{403},
+				PassStatusCodes:  []int{200, 404},
+				CheckBlockFunc:   tc.signature,
+			},
+		}
+		// The benign payload got a similar page, so the request looks
+		// rejected by the application
+		s.baselines = map[string]*baseline{
+			baselineKey(ph, ""): {
+				fingerprint: newFingerprint(newTestResponse(400, rejectedPage)),
+				rejected:    true,
+			},
+		}
+
+		pc := &payloadConfig{
+			payload:        "<script>",
+			encoder:        "Plain",
+			placeholder:    ph,
+			setName:        "owasp",
+			caseName:       "xss",
+			isTruePositive: true,
+		}
+
+		ts := &testStatus{}
+		err = s.updateDB(context.Background(), pc, ts, nil, newTestResponse(400, rejectedPage), nil, "", false)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		if ts.blockedTest == nil || ts.unresolvedTest != nil {
+			t.Errorf("%s: the block is overridden by the baseline", tc.name)
+		}
+	}
+}
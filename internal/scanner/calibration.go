@@ -0,0 +1,361 @@
+package scanner
+
+import (
+	"context"
+	"encoding/hex"
+	"hash/fnv"
+	"html"
+	"math/bits"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/wallarm/gotestwaf/internal/db"
+	p "github.com/wallarm/gotestwaf/internal/payload"
+	"github.com/wallarm/gotestwaf/internal/payload/encoder"
+	"github.com/wallarm/gotestwaf/internal/payload/placeholder"
+	"github.com/wallarm/gotestwaf/internal/scanner/types"
+)
+
+const (
+	// similarityThreshold is the minimum similarity of a response to the
+	// learned fingerprints to be classified.
+	similarityThreshold = 0.75
+
+	calibrationBenignRequests = 3
+	calibrationPayloadLength  = 12
+)
+
+// calibrationPayloads are sent to learn the block page.
+var calibrationPayloads = []string{
+	`<script>alert("XSS");</script>`,
+	`' UNION SELECT ALL FROM information_schema AND ' or SLEEP(5) or '`,
+	`../../../../etc/passwd`,
+	`/bin/cat /etc/passwd; ping 127.0.0.1; curl google.com`,
+}
+
+// Weights of the fingerprint features in the similarity score.
+const (
+	statusCodeWeight = 0.35
+	bodyWeight       = 0.35
+	titleWeight      = 0.1
+	lengthWeight     = 0.1
+	headersWeight    = 0.1
+)
+
+// volatileHeaders are excluded from the header set of a fingerprint.
+var volatileHeaders = map[string]struct{}{
+	"Date":           {},
+	"Content-Length": {},
+	"Set-Cookie":     {},
+	"Expires":        {},
+	"Last-Modified":  {},
+	"Etag":           {},
+	"Age":            {},
+}
+
+var titleRegex = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// fingerprint describes a response for the similarity comparison.
+type fingerprint struct {
+	statusCode int
+	headers    map[string]struct{}
+	length     int
+	title      string
+	simhash    uint64
+}
+
+func newFingerprint(resp types.Response) *fingerprint {
+	body := resp.GetContent()
+
+	fp := &fingerprint{
+		statusCode: resp.GetStatusCode(),
+		headers:    make(map[string]struct{}),
+		length:     len(body),
+		simhash:    simhash(body),
+	}
+
+	for header := range resp.GetHeaders() {
+		header = http.CanonicalHeaderKey(header)
+		if _, ok := volatileHeaders[header]; !ok {
+			fp.headers[header] = struct{}{}
+		}
+	}
+
+	if m := titleRegex.FindSubmatch(body); m != nil {
+		fp.title = strings.TrimSpace(html.UnescapeString(string(m[1])))
+	}
+
+	return fp
+}
+
+// similarity returns the similarity of two fingerprints from 0 to 1.
+func (fp *fingerprint) similarity(other *fingerprint) float64 {
+	var score float64
+
+	if fp.statusCode == other.statusCode {
+		score += statusCodeWeight
+	}
+
+	if fp.title == other.title {
+		score += titleWeight
+	}
+
+	distance := bits.OnesCount64(fp.simhash ^ other.simhash)
+	score += bodyWeight * (1 - float64(distance)/64)
+
+	minLen, maxLen := fp.length, other.length
+	if minLen > maxLen {
+		minLen, maxLen = maxLen, minLen
+	}
+	if maxLen == 0 {
+		score += lengthWeight
+	} else {
+		score += lengthWeight * float64(minLen) / float64(maxLen)
+	}
+
+	var common int
+	for header := range fp.headers {
+		if _, ok := other.headers[header]; ok {
+			common++
+		}
+	}
+	if union := len(fp.headers) + len(other.headers) - common; union == 0 {
+		score += headersWeight
+	} else {
+		score += headersWeight * float64(common) / float64(union)
+	}
+
+	return score
+}
+
+// maxSimilarity returns the highest similarity of fp to the fingerprints.
+func (fp *fingerprint) maxSimilarity(fingerprints []*fingerprint) float64 {
+	var result float64
+	for _, other := range fingerprints {
+		if s := fp.similarity(other); s > result {
+			result = s
+		}
+	}
+
+	return result
+}
+
+// simhash calculates the 64-bit simhash of the words of the body.
+func simhash(body []byte) uint64 {
+	var vector [64]int
+
+	words := strings.FieldsFunc(strings.ToLower(string(body)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	h := fnv.New64a()
+	for _, word := range words {
+		h.Reset()
+		h.Write([]byte(word))
+		sum := h.Sum64()
+
+		for i := 0; i < 64; i++ {
+			if sum&(1<<i) != 0 {
+				vector[i]++
+			} else {
+				vector[i]--
+			}
+		}
+	}
+
+	var result uint64
+	for i := 0; i < 64; i++ {
+		if vector[i] > 0 {
+			result |= 1 << i
+		}
+	}
+
+	return result
+}
+
+// blockModel contains fingerprints of benign and blocked responses learned
+// for a placeholder.
+type blockModel struct {
+	benign  []*fingerprint
+	blocked []*fingerprint
+}
+
+// classify compares the response with the learned fingerprints. It returns
+// ok=false if the response is not similar enough to any of them.
+func (m *blockModel) classify(resp types.Response) (blocked bool, confidence float64, ok bool) {
+	fp := newFingerprint(resp)
+
+	blockedSimilarity := fp.maxSimilarity(m.blocked)
+	benignSimilarity := fp.maxSimilarity(m.benign)
+
+	if blockedSimilarity < similarityThreshold && benignSimilarity < similarityThreshold {
+		return false, 0, false
+	}
+
+	if blockedSimilarity > benignSimilarity {
+		return true, blockedSimilarity / (blockedSimilarity + benignSimilarity), true
+	}
+
+	return false, benignSimilarity / (blockedSimilarity + benignSimilarity), true
+}
+
+// calibration contains block models by the placeholder key.
+type calibration struct {
+	models map[string]*blockModel
+}
+
+func placeholderKey(ph *db.Placeholder) string {
+	return hex.EncodeToString(ph.Hash())
+}
+
+// classify classifies the response using the model learned for the
+// placeholder.
+func (c *calibration) classify(ph *db.Placeholder, resp types.Response) (blocked bool, confidence float64, ok bool) {
+	if c == nil || ph == nil || resp == nil {
+		return false, 0, false
+	}
+
+	model, found := c.models[placeholderKey(ph)]
+	if !found {
+		return false, 0, false
+	}
+
+	return model.classify(resp)
+}
+
+// Calibrate sends benign and known malicious requests for each placeholder
+// used by the test cases and learns how the blocked responses differ from
+// the normal ones. The learned fingerprints are used to classify responses
+// instead of the status codes.
+func (s *Scanner) Calibrate(ctx context.Context) error {
+	s.logger.WithField("status", "started").Info("Calibration")
+
+	c := &calibration{models: make(map[string]*blockModel)}
+	seen := make(map[string]struct{})
+
+	for _, testCase := range s.db.GetTestCases() {
+		for _, ph := range testCase.Placeholders {
+			if ph.Name == placeholder.DefaultGRPC.GetName() || ph.Name == placeholder.DefaultGraphQL.GetName() {
+				continue
+			}
+
+			key := placeholderKey(ph)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+
+			model, err := s.learnBlockModel(ctx, ph)
+			if err != nil {
+				return errors.Wrapf(err, "couldn't calibrate placeholder %s", ph.Name)
+			}
+
+			if model == nil {
+				s.logger.WithField("placeholder", ph.Name).
+					Warn("Blocked responses can't be distinguished from normal ones, status codes and regexes are used")
+				continue
+			}
+
+			c.models[key] = model
+		}
+	}
+
+	s.calibration = c
+
+	s.logger.WithFields(logrus.Fields{
+		"status":       "done",
+		"placeholders": len(c.models),
+	}).Info("Calibration")
+
+	return nil
+}
+
+// learnBlockModel sends calibration requests through the placeholder. It
+// returns nil if blocked responses aren't distinguishable from benign ones.
+func (s *Scanner) learnBlockModel(ctx context.Context, ph *db.Placeholder) (*blockModel, error) {
+	model := &blockModel{}
+
+	encoderName := calibrationEncoder(ph.Name)
+
+	send := func(payload string) (*fingerprint, error) {
+		pl := &p.PayloadInfo{
+			Payload:           payload,
+			EncoderName:       encoderName,
+			PlaceholderName:   ph.Name,
+			PlaceholderConfig: ph.Config,
+		}
+
+		resp, err := s.httpClient.SendPayload(ctx, s.cfg.URL, pl)
+		if err == nil {
+			err = resp.GetError()
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return newFingerprint(resp), nil
+	}
+
+	for i := 0; i < calibrationBenignRequests; i++ {
+		fp, err := send(randomWord(calibrationPayloadLength))
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't send benign request")
+		}
+
+		model.benign = append(model.benign, fp)
+	}
+
+	for _, payload := range calibrationPayloads {
+		// The WAF may reset the connection instead of sending a block page
+		fp, err := send(payload)
+		if err != nil {
+			s.logger.WithError(err).WithField("placeholder", ph.Name).
+				Debug("Couldn't send calibration request")
+			continue
+		}
+
+		// Payloads that passed look like benign responses and don't
+		// describe the block page
+		if fp.maxSimilarity(model.benign) < similarityThreshold {
+			model.blocked = append(model.blocked, fp)
+		}
+	}
+
+	if len(model.blocked) == 0 {
+		return nil, nil
+	}
+
+	return model, nil
+}
+
+// calibrationEncoder returns the encoder of the calibration payloads for the
+// placeholder. The payloads are URL encoded in the URL and the headers, and
+// sent as is in the request body and the raw requests.
+func calibrationEncoder(placeholderName string) string {
+	switch placeholderName {
+	case placeholder.DefaultURLParam.GetName(),
+		placeholder.DefaultURLPath.GetName(),
+		placeholder.DefaultHeader.GetName(),
+		placeholder.DefaultUserAgent.GetName():
+		return encoder.DefaultURLEncoder.GetName()
+	default:
+		return encoder.DefaultPlainEncoder.GetName()
+	}
+}
+
+func randomWord(n int) string {
+	const letters = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = letters[rand.Intn(len(letters))]
+	}
+
+	return string(b)
+}
@@ -0,0 +1,133 @@
+package scanner
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/wallarm/gotestwaf/internal/config"
+	"github.com/wallarm/gotestwaf/internal/db"
+	"github.com/wallarm/gotestwaf/internal/payload"
+	"github.com/wallarm/gotestwaf/internal/scanner/types"
+)
+
+func newTestResponse(statusCode int, body string) types.Response {
+	return &types.ResponseMeta{
+		StatusCode: statusCode,
+		Headers:    http.Header{"Content-Type": []string{"text/html"}},
+		Content:    []byte(body),
+	}
+}
+
+func TestBlockModelClassify(t *testing.T) {
+	const (
+		appPage   = "<html><title>Shop</title><body>Welcome to our shop. Products: phones, laptops, tablets and more.</body></html>"
+		blockPage = "<html><title>Access denied</title><body>Request blocked by security policy. Incident ID: 1234567</body></html>"
+	)
+
+	model := &blockModel{
+		benign:  []*fingerprint{newFingerprint(newTestResponse(200, appPage))},
+		blocked: []*fingerprint{newFingerprint(newTestResponse(200, blockPage))},
+	}
+
+	testCases := []struct {
+		name    string
+		resp    types.Response
+		blocked bool
+		ok      bool
+	}{
+		{
+			name:    "block page",
+			resp:    newTestResponse(200, "<html><title>Access denied</title><body>Request blocked by security policy. Incident ID: 7654321</body></html>"),
+			blocked: true,
+			ok:      true,
+		},
+		{
+			name:    "app page",
+			resp:    newTestResponse(200, appPage),
+			blocked: false,
+			ok:      true,
+		},
+		{
+			name: "unknown page",
+			resp: &types.ResponseMeta{StatusCode: 500, Content: []byte("Internal Server Error")},
+			ok:   false,
+		},
+	}
+
+	for _, tc := range testCases {
+		blocked, confidence, ok := model.classify(tc.resp)
+		if ok != tc.ok {
+			t.Fatalf("%s: got ok %v, want %v", tc.name, ok, tc.ok)
+		}
+		if !ok {
+			continue
+		}
+
+		if blocked != tc.blocked {
+			t.Errorf("%s: got blocked %v, want %v", tc.name, blocked, tc.blocked)
+		}
+		if confidence <= 0.5 || confidence > 1 {
+			t.Errorf("%s: unexpected confidence %f", tc.name, confidence)
+		}
+	}
+}
+
+// calibrationClient blocks the calibration payloads and fails the request
+// with the first one.
+type calibrationClient struct {
+	encoders map[string]struct{}
+}
+
+func (c *calibrationClient) SendPayload(_ context.Context, _ string, pl *payload.PayloadInfo) (types.Response, error) {
+	c.encoders[pl.EncoderName] = struct{}{}
+
+	switch pl.Payload {
+	case calibrationPayloads[0]:
+		return &types.ResponseMeta{Error: "connection reset"}, nil
+	case calibrationPayloads[1], calibrationPayloads[2], calibrationPayloads[3]:
+		return newTestResponse(403, "<html><title>Access denied</title><body>Request blocked</body></html>"), nil
+	default:
+		return newTestResponse(200, "<html><title>Shop</title><body>Welcome to our shop</body></html>"), nil
+	}
+}
+
+func (c *calibrationClient) SendRequest(context.Context, types.Request) (types.Response, error) {
+	return nil, nil
+}
+
+func TestLearnBlockModel(t *testing.T) {
+	testCases := []struct {
+		placeholder string
+		encoder     string
+	}{
+		{placeholder: "URLParam", encoder: "URL"},
+		{placeholder: "JSONBody", encoder: "Plain"},
+		{placeholder: "RawRequest", encoder: "Plain"},
+	}
+
+	for _, tc := range testCases {
+		client := &calibrationClient{encoders: make(map[string]struct{})}
+		s := &Scanner{
+			logger:     logrus.New(),
+			cfg:        &config.Config{},
+			httpClient: client,
+		}
+
+		model, err := s.learnBlockModel(context.Background(), &db.Placeholder{Name: tc.placeholder})
+		if err != nil {
+			t.Fatalf("%s: couldn't learn block model: %v", tc.placeholder, err)
+		}
+
+		if _, ok := client.encoders[tc.encoder]; !ok || len(client.encoders) != 1 {
+			t.Errorf("%s: got encoders %v, want %s", tc.placeholder, client.encoders, tc.encoder)
+		}
+
+		// The failed request isn't learned as a block page
+		if model == nil || len(model.blocked) != len(calibrationPayloads)-1 {
+			t.Errorf("%s: the failed response is learned as blocked", tc.placeholder)
+		}
+	}
+}
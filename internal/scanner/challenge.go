@@ -0,0 +1,174 @@
+package scanner
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/wallarm/gotestwaf/internal/config"
+	"github.com/wallarm/gotestwaf/internal/scanner/clients/chrome"
+	"github.com/wallarm/gotestwaf/internal/scanner/clients/gohttp"
+	"github.com/wallarm/gotestwaf/internal/scanner/types"
+)
+
+// isJSChallenge checks if the response content is a JavaScript challenge page.
+func isJSChallenge(content string) bool {
+	for i := range jsChallengeErrorMsgs {
+		if strings.Contains(content, jsChallengeErrorMsgs[i]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sessionClient sends requests with the browser session of the solved
+// challenge.
+type sessionClient interface {
+	SetBrowserSession(cookies []*http.Cookie, userAgent string)
+}
+
+// challengeSolver solves JavaScript challenges in a headless Chrome and passes
+// the browser session to the gohttp client and the other session clients.
+type challengeSolver struct {
+	logger    *logrus.Logger
+	targetURL string
+	timeout   time.Duration
+
+	browser        *chrome.Client
+	httpClient     *gohttp.Client
+	sessionClients []sessionClient
+
+	mu sync.Mutex
+	// generation is incremented each time the challenge is solved
+	generation atomic.Uint64
+}
+
+func newChallengeSolver(
+	logger *logrus.Logger,
+	cfg *config.Config,
+	httpClient *gohttp.Client,
+	sessionClients ...sessionClient,
+) (*challengeSolver, error) {
+	browser, err := chrome.NewClient(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't create Chrome client")
+	}
+
+	return &challengeSolver{
+		logger:         logger,
+		targetURL:      cfg.URL,
+		timeout:        time.Duration(cfg.JSChallengeTimeout) * time.Second,
+		browser:        browser,
+		httpClient:     httpClient,
+		sessionClients: append([]sessionClient{httpClient}, sessionClients...),
+	}, nil
+}
+
+// solve solves the challenge and passes the browser session to the session
+// clients. The caller must hold the mutex.
+func (c *challengeSolver) solve(ctx context.Context) error {
+	c.logger.WithField("url", c.targetURL).Info("Solving JavaScript challenge")
+
+	solution, err := c.browser.SolveChallenge(ctx, c.targetURL, c.timeout, isJSChallenge)
+	if err != nil {
+		return errors.Wrap(err, "couldn't solve JavaScript challenge")
+	}
+
+	for _, client := range c.sessionClients {
+		client.SetBrowserSession(solution.Cookies, solution.UserAgent)
+	}
+	c.generation.Add(1)
+
+	c.logger.WithFields(logrus.Fields{
+		"cookies":    len(solution.Cookies),
+		"user_agent": solution.UserAgent,
+	}).Info("JavaScript challenge solved")
+
+	return nil
+}
+
+// Solve solves the challenge and checks that the gohttp client passes it
+// with the browser session.
+func (c *challengeSolver) Solve(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.solve(ctx); err != nil {
+		return err
+	}
+
+	challenged, err := c.isChallenged(ctx)
+	if err != nil {
+		return err
+	}
+
+	if challenged {
+		return errors.New("the challenge is still shown with the browser session, " +
+			"it may be bound to the browser TLS fingerprint. Use --httpClient=chrome instead")
+	}
+
+	return nil
+}
+
+// Generation returns the number of the current browser session. It must be
+// taken before sending a request and passed to Renew.
+func (c *challengeSolver) Generation() uint64 {
+	return c.generation.Load()
+}
+
+// Renew is called when a test request got a challenge page. The challenge
+// can be the WAF reaction to the payload, or the browser session may have
+// expired. A benign request is sent to distinguish these cases, and the
+// challenge is solved again if the session has expired. It returns true if
+// the test request should be resent.
+func (c *challengeSolver) Renew(ctx context.Context, generation uint64) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// The session was renewed while the request was being sent
+	if c.generation.Load() != generation {
+		return true, nil
+	}
+
+	challenged, err := c.isChallenged(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if !challenged {
+		return false, nil
+	}
+
+	if err = c.solve(ctx); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// isChallenged sends a benign request to the target and checks if a
+// challenge is returned.
+func (c *challengeSolver) isChallenged(ctx context.Context) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.targetURL, nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := c.httpClient.SendRequest(ctx, &types.GoHTTPRequest{Req: req})
+	if err != nil {
+		return false, errors.Wrap(err, "couldn't send benign request")
+	}
+
+	return isJSChallenge(string(resp.GetContent())), nil
+}
+
+func (c *challengeSolver) Close() error {
+	return c.browser.Close()
+}
@@ -0,0 +1,110 @@
+package chrome
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/pkg/errors"
+
+	"github.com/wallarm/gotestwaf/internal/payload/placeholder"
+)
+
+const challengePollInterval = 500 * time.Millisecond
+
+// ChallengeSolution contains the browser state after a JavaScript challenge
+// is passed. It can be used by other HTTP clients to continue the session.
+type ChallengeSolution struct {
+	Cookies   []*http.Cookie
+	UserAgent string
+}
+
+// SolveChallenge opens the target URL in a new tab and waits until
+// isChallenge reports that the page is not a challenge anymore. Challenge
+// cookies are usually bound to the User-Agent, so the configured User-Agent
+// is used for both HTTP requests and JavaScript.
+func (c *Client) SolveChallenge(
+	ctx context.Context,
+	targetURL string,
+	timeout time.Duration,
+	isChallenge func(content string) bool,
+) (*ChallengeSolution, error) {
+	tabCtx, tabCancel, err := c.newTab(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tabCancel()
+
+	var tasks chromedp.Tasks
+
+	headers := c.extraHeaders()
+	for k, v := range headers {
+		if strings.EqualFold(k, placeholder.UAHeader) {
+			tasks = append(tasks, emulation.SetUserAgentOverride(v.(string)))
+			delete(headers, k)
+		}
+	}
+
+	if len(headers) > 0 {
+		tasks = append(tasks, network.SetExtraHTTPHeaders(headers))
+	}
+	tasks = append(tasks, chromedp.Navigate(targetURL))
+
+	if err = chromedp.Run(tabCtx, tasks); err != nil {
+		return nil, errors.Wrap(err, "couldn't open the target URL")
+	}
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		var content string
+
+		// The page may be reloaded by the challenge script while we're
+		// reading it, so errors are ignored and the content is read again
+		err = chromedp.Run(tabCtx, chromedp.OuterHTML("html", &content, chromedp.ByQuery))
+		if err == nil && !isChallenge(content) {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline.C:
+			return nil, errors.Errorf("the challenge wasn't solved in %s", timeout)
+		case <-time.After(challengePollInterval):
+		}
+	}
+
+	solution := &ChallengeSolution{}
+
+	err = chromedp.Run(tabCtx,
+		chromedp.Evaluate(`navigator.userAgent`, &solution.UserAgent),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			cookies, err := network.GetCookies().WithURLs([]string{targetURL}).Do(ctx)
+			if err != nil {
+				return err
+			}
+
+			for _, cookie := range cookies {
+				solution.Cookies = append(solution.Cookies, &http.Cookie{
+					Name:   cookie.Name,
+					Value:  cookie.Value,
+					Path:   cookie.Path,
+					Domain: cookie.Domain,
+				})
+			}
+
+			return nil
+		}),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't get the browser session")
+	}
+
+	return solution, nil
+}
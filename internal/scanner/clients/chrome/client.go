@@ -2,8 +2,15 @@ package chrome
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/network"
@@ -27,9 +34,17 @@ var DefaultChromeDPExecAllocatorOptions = append(
 	chromedp.Flag("disable-web-security", true),
 )
 
+// Client sends requests using a headless Chrome. A single browser is started
+// on the first request and reused for the whole scan, each request is
+// performed in a new tab.
 type Client struct {
-	execAllocatorOptions []chromedp.ExecAllocatorOption
-	disableLogs          bool
+	allocCtx      context.Context
+	allocCancel   context.CancelFunc
+	browserCtx    context.Context
+	browserCancel context.CancelFunc
+
+	browserMu      sync.Mutex
+	browserStarted bool
 
 	headers map[string]string
 }
@@ -54,7 +69,7 @@ func NewClient(cfg *config.Config) (*Client, error) {
 		)
 	}
 
-	if !cfg.TLSVerify {
+	if !helpers.VerifyServerCert(cfg) {
 		execAllocatorOptions = append(
 			execAllocatorOptions,
 			chromedp.Flag("ignore-certificate-errors", "1"),
@@ -62,6 +77,13 @@ func NewClient(cfg *config.Config) (*Client, error) {
 		)
 	}
 
+	tlsOptions, err := tlsExecAllocatorOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	execAllocatorOptions = append(execAllocatorOptions, tlsOptions...)
+
 	configuredHeaders := helpers.DeepCopyMap(cfg.HTTPHeaders)
 	for k := range configuredHeaders {
 		if strings.EqualFold(k, "host") {
@@ -77,15 +99,80 @@ func NewClient(cfg *config.Config) (*Client, error) {
 		configuredHeaders[header] = value
 	}
 
+	var logOptions []chromedp.ContextOption
+	if disableLogs {
+		logOptions = append(
+			logOptions,
+			chromedp.WithLogf(discardLogs),
+			chromedp.WithDebugf(discardLogs),
+			chromedp.WithErrorf(discardLogs),
+		)
+	}
+
+	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), execAllocatorOptions...)
+	browserCtx, browserCancel := chromedp.NewContext(allocCtx, logOptions...)
+
 	c := &Client{
-		execAllocatorOptions: execAllocatorOptions,
-		disableLogs:          disableLogs,
-		headers:              configuredHeaders,
+		allocCtx:      allocCtx,
+		allocCancel:   allocCancel,
+		browserCtx:    browserCtx,
+		browserCancel: browserCancel,
+		headers:       configuredHeaders,
 	}
 
 	return c, nil
 }
 
+// newTab opens a new browser tab. The browser is started on the first call.
+// The tab is closed when the returned cancel function is called or the
+// given context is done.
+func (c *Client) newTab(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	c.browserMu.Lock()
+	if !c.browserStarted {
+		// Running the browser context without actions starts the browser
+		if err := chromedp.Run(c.browserCtx); err != nil {
+			c.browserMu.Unlock()
+			return nil, nil, errors.Wrap(err, "couldn't start Chrome")
+		}
+
+		c.browserStarted = true
+	}
+	c.browserMu.Unlock()
+
+	tabCtx, tabCancel := chromedp.NewContext(c.browserCtx)
+	stop := context.AfterFunc(ctx, tabCancel)
+
+	cancel := func() {
+		stop()
+		tabCancel()
+	}
+
+	return tabCtx, cancel, nil
+}
+
+// Close stops the browser.
+func (c *Client) Close() error {
+	c.browserCancel()
+	c.allocCancel()
+
+	return nil
+}
+
+// extraHeaders returns the configured headers which can be set via
+// the Network.setExtraHTTPHeaders method.
+func (c *Client) extraHeaders() network.Headers {
+	headers := make(network.Headers)
+	for k, v := range c.headers {
+		if strings.EqualFold(k, "host") {
+			continue
+		}
+
+		headers[k] = v
+	}
+
+	return headers
+}
+
 func (c *Client) SendPayload(
 	ctx context.Context,
 	targetURL string,
@@ -101,32 +188,18 @@ func (c *Client) SendPayload(
 		return nil, errors.Errorf("bad request type: %T, expected %T", request, &types.ChromeDPTasks{})
 	}
 
-	// Create a new Chrome allocator context
-	allocCtx, allocCtxCancel := chromedp.NewExecAllocator(ctx, c.execAllocatorOptions...)
-	defer allocCtxCancel()
-
-	var logOptions []chromedp.ContextOption
-	if c.disableLogs {
-		logOptions = append(
-			logOptions,
-			chromedp.WithLogf(discardLogs),
-			chromedp.WithDebugf(discardLogs),
-			chromedp.WithErrorf(discardLogs),
-		)
+	// Open a new tab in the shared browser
+	chromeCtx, chromeCtxCancel, err := c.newTab(ctx)
+	if err != nil {
+		return nil, err
 	}
-
-	// Create a new Chrome context
-	chromeCtx, chromeCtxCancel := chromedp.NewContext(allocCtx, logOptions...)
 	defer chromeCtxCancel()
 
-	headers := make(network.Headers)
-	for k, v := range c.headers {
-		if strings.EqualFold(k, "host") {
-			continue
-		}
+	headers := c.extraHeaders()
 
-		headers[k] = v
-	}
+	// Payloads are sent by the fetch API, other requests made by the page
+	// are not recorded
+	recorder := &requestRecorder{resourceType: network.ResourceTypeFetch}
 
 	var wg sync.WaitGroup
 	errorChan := make(chan error, 10)
@@ -154,13 +227,9 @@ func (c *Client) SendPayload(
 			headers[k] = v
 		}
 
-		tasks = chromedp.Tasks{}
-		if len(headers) > 0 {
-			tasks = chromedp.Tasks{network.SetExtraHTTPHeaders(headers)}
-		}
-		tasks = append(tasks, r.Tasks...)
+		recorder.listen(chromeCtx)
 
-		if err := chromedp.Run(chromeCtx, tasks); err != nil {
+		if err := chromedp.Run(chromeCtx, payloadTasks(r, headers)); err != nil {
 			errorChan <- errors.Wrap(err, "failed to execute Chrome tasks")
 		}
 
@@ -188,9 +257,23 @@ forLoop:
 		return nil, err
 	}
 
+	r.ResponseMeta.Request = recorder.lastRequest()
+
 	return r.ResponseMeta, nil
 }
 
+// payloadTasks returns the tasks which send the payload with the headers.
+// The latency of the response is measured by the tasks.
+func payloadTasks(r *types.ChromeDPTasks, headers network.Headers) chromedp.Tasks {
+	var tasks chromedp.Tasks
+	if len(headers) > 0 {
+		tasks = chromedp.Tasks{network.SetExtraHTTPHeaders(headers)}
+	}
+
+	// The fetch task returns after the response body is read
+	return append(tasks, timed(r.Tasks, &r.ResponseMeta.Latency))
+}
+
 func (c *Client) SendRequest(
 	ctx context.Context,
 	req types.Request,
@@ -200,32 +283,14 @@ func (c *Client) SendRequest(
 		return nil, errors.Errorf("bad request type: %T, expected %T", req, &types.ChromeDPTasks{})
 	}
 
-	// Create a new Chrome allocator context
-	allocCtx, allocCtxCancel := chromedp.NewExecAllocator(ctx, c.execAllocatorOptions...)
-	defer allocCtxCancel()
-
-	var logOptions []chromedp.ContextOption
-	if c.disableLogs {
-		logOptions = append(
-			logOptions,
-			chromedp.WithLogf(discardLogs),
-			chromedp.WithDebugf(discardLogs),
-			chromedp.WithErrorf(discardLogs),
-		)
+	// Open a new tab in the shared browser
+	chromeCtx, chromeCtxCancel, err := c.newTab(ctx)
+	if err != nil {
+		return nil, err
 	}
-
-	// Create a new Chrome context
-	chromeCtx, chromeCtxCancel := chromedp.NewContext(allocCtx, logOptions...)
 	defer chromeCtxCancel()
 
-	headers := make(network.Headers)
-	for k, v := range c.headers {
-		if strings.EqualFold(k, "host") {
-			continue
-		}
-
-		headers[k] = v
-	}
+	headers := c.extraHeaders()
 
 	if r.DebugHeaderValue != "" {
 		headers[clients.GTWDebugHeader] = r.DebugHeaderValue
@@ -237,7 +302,6 @@ func (c *Client) SendRequest(
 	}
 	tasks = append(tasks, r.Tasks...)
 
-	var err error
 	var wg sync.WaitGroup
 	errorChan := make(chan error, 10)
 
@@ -250,6 +314,9 @@ func (c *Client) SendRequest(
 		return nil, errors.Wrap(err, "couldn't enable network domain")
 	}
 
+	recorder := &requestRecorder{}
+	recorder.listen(chromeCtx)
+
 	// Listen for network events
 	chromedp.ListenTarget(chromeCtx, func(ev interface{}) {
 		if ev, ok := ev.(*network.EventResponseReceived); ok {
@@ -274,6 +341,11 @@ func (c *Client) SendRequest(
 					Content:      body,
 				}
 
+				// The timing is relative to the request start, in milliseconds
+				if response.Timing != nil {
+					info.Latency = time.Duration(response.Timing.ReceiveHeadersEnd * float64(time.Millisecond))
+				}
+
 				// Update the latest response
 				latestResponse = info
 			}()
@@ -312,9 +384,101 @@ forLoop:
 		return nil, err
 	}
 
+	if latestResponse != nil {
+		latestResponse.Request = recorder.lastRequest()
+	}
+
 	return latestResponse, nil
 }
 
 // discardLogs serves as a no-op logging function for chromedp
 // to suppress all internal logging output.
 func discardLogs(string, ...interface{}) {}
+
+// chromeTLSVersions maps TLS versions to the values of the Chrome
+// --ssl-version-min and --ssl-version-max flags.
+var chromeTLSVersions = map[uint16]string{
+	tls.VersionTLS10: "tls1",
+	tls.VersionTLS11: "tls1.1",
+	tls.VersionTLS12: "tls1.2",
+	tls.VersionTLS13: "tls1.3",
+}
+
+// tlsExecAllocatorOptions converts the TLS settings to Chrome command line
+// flags. Chrome can't be configured with a client certificate or an SNI value
+// from the command line, so these settings are rejected.
+func tlsExecAllocatorOptions(cfg *config.Config) ([]chromedp.ExecAllocatorOption, error) {
+	var opts []chromedp.ExecAllocatorOption
+
+	if cfg.TLSClientCert != "" || cfg.TLSClientKey != "" {
+		return nil, errors.New("client certificates are not supported by the chrome client")
+	}
+
+	if cfg.TLSServerName != "" {
+		return nil, errors.New("custom TLS server name is not supported by the chrome client")
+	}
+
+	minVersion, err := helpers.ParseTLSVersion(cfg.TLSMinVersion)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't parse minimum TLS version")
+	}
+	if minVersion != 0 {
+		opts = append(opts, chromedp.Flag("ssl-version-min", chromeTLSVersions[minVersion]))
+	}
+
+	maxVersion, err := helpers.ParseTLSVersion(cfg.TLSMaxVersion)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't parse maximum TLS version")
+	}
+	if maxVersion != 0 {
+		opts = append(opts, chromedp.Flag("ssl-version-max", chromeTLSVersions[maxVersion]))
+	}
+
+	if cfg.TLSCA != "" {
+		spkiList, err := caSPKIHashes(cfg.TLSCA)
+		if err != nil {
+			return nil, err
+		}
+
+		// Chrome has no option to add a trusted CA, but it can trust
+		// certificates chained to the given public keys.
+		opts = append(opts, chromedp.Flag("ignore-certificate-errors-spki-list", strings.Join(spkiList, ",")))
+	}
+
+	return opts, nil
+}
+
+// caSPKIHashes returns base64 encoded SHA-256 hashes of the public keys of all
+// certificates from the given PEM file.
+func caSPKIHashes(caFile string) ([]string, error) {
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't read CA bundle")
+	}
+
+	var hashes []string
+	for {
+		var block *pem.Block
+		block, caPEM = pem.Decode(caPEM)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't parse CA certificate")
+		}
+
+		hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+		hashes = append(hashes, base64.StdEncoding.EncodeToString(hash[:]))
+	}
+
+	if len(hashes) == 0 {
+		return nil, errors.Errorf("couldn't find any PEM encoded certificates in %s", caFile)
+	}
+
+	return hashes, nil
+}
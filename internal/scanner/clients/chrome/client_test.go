@@ -0,0 +1,109 @@
+package chrome
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/chromedp/chromedp"
+
+	"github.com/wallarm/gotestwaf/internal/config"
+	"github.com/wallarm/gotestwaf/internal/payload"
+	"github.com/wallarm/gotestwaf/internal/scanner/types"
+)
+
+func TestTimed(t *testing.T) {
+	var d time.Duration
+
+	tasks := timed(chromedp.Tasks{
+		chromedp.ActionFunc(func(context.Context) error {
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		}),
+	}, &d)
+
+	if err := tasks.Do(context.Background()); err != nil {
+		t.Fatalf("couldn't run tasks: %v", err)
+	}
+
+	if d < 10*time.Millisecond {
+		t.Errorf("measured %s, want at least 10ms", d)
+	}
+}
+
+func TestPayloadTasksLatency(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte("OK"))
+	}))
+	defer srv.Close()
+
+	// The fetch task is replaced by a request sent without the browser
+	r := &types.ChromeDPTasks{
+		Tasks: chromedp.Tasks{
+			chromedp.ActionFunc(func(ctx context.Context) error {
+				resp, err := http.Get(srv.URL)
+				if err != nil {
+					return err
+				}
+				defer resp.Body.Close()
+
+				_, err = io.ReadAll(resp.Body)
+				return err
+			}),
+		},
+		ResponseMeta: &types.ResponseMeta{},
+	}
+
+	if err := payloadTasks(r, nil).Do(context.Background()); err != nil {
+		t.Fatalf("couldn't run tasks: %v", err)
+	}
+
+	if r.ResponseMeta.GetLatency() < 50*time.Millisecond {
+		t.Errorf("latency is %s, want at least 50ms", r.ResponseMeta.GetLatency())
+	}
+}
+
+func TestSendPayloadLatency(t *testing.T) {
+	found := false
+	for _, name := range []string{"headless-shell", "chromium", "chromium-browser", "google-chrome"} {
+		if _, err := exec.LookPath(name); err == nil {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Skip("Chrome is not installed")
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RawQuery != "" {
+			time.Sleep(50 * time.Millisecond)
+		}
+		w.Write([]byte("OK"))
+	}))
+	defer srv.Close()
+
+	client, err := NewClient(&config.Config{})
+	if err != nil {
+		t.Fatalf("couldn't create client: %v", err)
+	}
+	defer client.Close()
+
+	resp, err := client.SendPayload(context.Background(), srv.URL, &payload.PayloadInfo{
+		Payload:         "test",
+		EncoderName:     "Plain",
+		PlaceholderName: "URLParam",
+	})
+	if err != nil {
+		t.Fatalf("couldn't send payload: %v", err)
+	}
+
+	if resp.GetLatency() < 50*time.Millisecond {
+		t.Errorf("latency is %s, want at least 50ms", resp.GetLatency())
+	}
+}
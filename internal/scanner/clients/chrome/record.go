@@ -0,0 +1,72 @@
+package chrome
+
+import (
+	"context"
+	"encoding/base64"
+	"sync"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+
+	"github.com/wallarm/gotestwaf/internal/scanner/types"
+)
+
+// requestRecorder keeps the last request sent by a browser tab.
+type requestRecorder struct {
+	// resourceType limits the recorded requests to the given type,
+	// all requests are recorded if it is empty
+	resourceType network.ResourceType
+
+	mu      sync.Mutex
+	request *types.RequestMeta
+}
+
+// listen starts recording the requests sent by the tab. The Network domain
+// must be enabled in the tab.
+func (r *requestRecorder) listen(ctx context.Context) {
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		e, ok := ev.(*network.EventRequestWillBeSent)
+		if !ok || e.Request == nil {
+			return
+		}
+		if r.resourceType != "" && e.Type != r.resourceType {
+			return
+		}
+
+		meta := &types.RequestMeta{
+			Method:  e.Request.Method,
+			URL:     e.Request.URL + e.Request.URLFragment,
+			Headers: headersToMap(e.Request.Headers),
+			Body:    postData(e.Request),
+		}
+
+		r.mu.Lock()
+		r.request = meta
+		r.mu.Unlock()
+	})
+}
+
+// lastRequest returns the last recorded request or nil.
+func (r *requestRecorder) lastRequest() *types.RequestMeta {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.request
+}
+
+// postData joins the request body entries. The entries may be omitted by
+// Chrome if the body is too long.
+func postData(req *network.Request) []byte {
+	var body []byte
+
+	for _, entry := range req.PostDataEntries {
+		data, err := base64.StdEncoding.DecodeString(entry.Bytes)
+		if err != nil {
+			continue
+		}
+
+		body = append(body, data...)
+	}
+
+	return body
+}
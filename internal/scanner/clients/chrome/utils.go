@@ -1,8 +1,12 @@
 package chrome
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"time"
+
+	"github.com/chromedp/chromedp"
 )
 
 // headersToMap converts network.Headers (map[string]interface{}) to
@@ -25,3 +29,21 @@ func headersToMap(headers map[string]interface{}) http.Header {
 
 	return result
 }
+
+// timed returns the tasks which store the execution time of the given tasks
+// in d.
+func timed(tasks chromedp.Tasks, d *time.Duration) chromedp.Tasks {
+	var start time.Time
+
+	return chromedp.Tasks{
+		chromedp.ActionFunc(func(context.Context) error {
+			start = time.Now()
+			return nil
+		}),
+		tasks,
+		chromedp.ActionFunc(func(context.Context) error {
+			*d = time.Since(start)
+			return nil
+		}),
+	}
+}
@@ -1,31 +1,27 @@
 package gohttp
 
 import (
-	"bytes"
 	"context"
-	"crypto/tls"
-	"io"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
 
+	"github.com/wallarm/gotestwaf/internal/auth"
 	"github.com/wallarm/gotestwaf/internal/config"
 	"github.com/wallarm/gotestwaf/internal/helpers"
 	"github.com/wallarm/gotestwaf/internal/payload"
 	"github.com/wallarm/gotestwaf/internal/payload/placeholder"
 	"github.com/wallarm/gotestwaf/internal/scanner/clients"
 	"github.com/wallarm/gotestwaf/internal/scanner/types"
+	"github.com/wallarm/gotestwaf/internal/signer"
 	"github.com/wallarm/gotestwaf/pkg/dnscache"
 )
 
-const (
-	getCookiesRepeatAttempts = 3
-)
-
 var redirectFunc func(req *http.Request, via []*http.Request) error
 
 var _ clients.HTTPClient = (*Client)(nil)
@@ -35,13 +31,35 @@ type Client struct {
 	headers    map[string]string
 	hostHeader string
 
+	readLimits *types.ReadLimits
+
 	followCookies bool
 	renewSession  bool
+
+	authenticator *auth.Authenticator
+
+	browserSession atomic.Pointer[browserSession]
+}
+
+// browserSession holds the cookies and the User-Agent of the browser which
+// has solved a JavaScript challenge.
+type browserSession struct {
+	cookies   []*http.Cookie
+	userAgent string
 }
 
-func NewClient(cfg *config.Config, dnsResolver *dnscache.Resolver) (*Client, error) {
+func NewClient(
+	cfg *config.Config,
+	dnsResolver *dnscache.Resolver,
+	authenticator *auth.Authenticator,
+) (*Client, error) {
+	tlsConf, err := helpers.NewTLSConfig(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't create TLS config")
+	}
+
 	tr := &http.Transport{
-		TLSClientConfig:     &tls.Config{InsecureSkipVerify: !cfg.TLSVerify},
+		TLSClientConfig:     tlsConf,
 		IdleConnTimeout:     time.Duration(cfg.IdleConnTimeout) * time.Second,
 		MaxIdleConns:        cfg.MaxIdleConns,
 		MaxIdleConnsPerHost: cfg.MaxIdleConns, // net.http hardcodes DefaultMaxIdleConnsPerHost to 2!
@@ -75,10 +93,21 @@ func NewClient(cfg *config.Config, dnsResolver *dnscache.Resolver) (*Client, err
 	}
 
 	client := &http.Client{
-		Transport:     tr,
+		Transport:     &recordTransport{base: tr},
 		CheckRedirect: redirectFunc,
 	}
 
+	// Requests are signed in the transport, after the payload is placed and
+	// all headers and cookies are set
+	requestSigner, err := signer.New(cfg.Signing)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't create request signer")
+	}
+
+	if requestSigner != nil {
+		client.Transport = &signer.Transport{Base: client.Transport, Signer: requestSigner}
+	}
+
 	if cfg.FollowCookies && !cfg.RenewSession {
 		jar, err := cookiejar.New(nil)
 		if err != nil {
@@ -100,8 +129,10 @@ func NewClient(cfg *config.Config, dnsResolver *dnscache.Resolver) (*Client, err
 		client:        client,
 		headers:       configuredHeaders,
 		hostHeader:    configuredHeaders["Host"],
+		readLimits:    types.NewReadLimits(cfg.MaxResponseSize, cfg.ResponseReadTimeout, cfg.StreamFirstEventOnly),
 		followCookies: cfg.FollowCookies,
 		renewSession:  cfg.RenewSession,
+		authenticator: authenticator,
 	}, nil
 }
 
@@ -120,7 +151,8 @@ func (c *Client) SendPayload(
 		return nil, errors.Errorf("bad request type: %T, expected %T", request, &types.GoHTTPRequest{})
 	}
 
-	req := r.Req.WithContext(ctx)
+	requestMeta := &types.RequestMeta{}
+	req := r.Req.WithContext(withRequestMeta(ctx, requestMeta))
 
 	isUAPlaceholder := payloadInfo.PlaceholderName == placeholder.DefaultUserAgent.GetName()
 
@@ -138,34 +170,24 @@ func (c *Client) SendPayload(
 	}
 	req.Host = c.hostHeader
 
+	c.applyBrowserSession(req, isUAPlaceholder)
+
 	if payloadInfo.DebugHeaderValue != "" {
 		req.Header.Set(clients.GTWDebugHeader, payloadInfo.DebugHeaderValue)
 	}
 
-	if c.followCookies && c.renewSession {
-		cookies, err := c.getCookies(ctx, targetURL)
-		if err != nil {
-			return nil, errors.Wrap(err, "couldn't get cookies for malicious request")
-		}
-
-		for _, cookie := range cookies {
-			req.AddCookie(cookie)
-		}
-	}
-
-	resp, err := c.client.Do(req)
+	resp, start, err := c.do(req)
 	if err != nil {
 		return nil, errors.Wrap(err, "sending http request")
 	}
 
-	bodyBytes, err := io.ReadAll(resp.Body)
+	bodyBytes, truncated, err := types.ReadBody(resp, c.readLimits)
 	if err != nil {
 		return nil, errors.Wrap(err, "reading response body")
 	}
 
-	// body reuse
-	resp.Body.Close()
-	resp.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+	latency := time.Since(start)
+	requestMeta.Proto = resp.Proto
 
 	statusCode := resp.StatusCode
 
@@ -181,6 +203,9 @@ func (c *Client) SendPayload(
 		StatusReason: reason,
 		Headers:      resp.Header,
 		Content:      bodyBytes,
+		Truncated:    truncated,
+		Latency:      latency,
+		Request:      requestMeta,
 	}
 
 	return response, nil
@@ -192,40 +217,33 @@ func (c *Client) SendRequest(ctx context.Context, req types.Request) (types.Resp
 		return nil, errors.Errorf("bad request type: %T, expected %T", req, &types.GoHTTPRequest{})
 	}
 
-	r.Req = r.Req.WithContext(ctx)
-
-	if c.followCookies && c.renewSession {
-		cookies, err := c.getCookies(ctx, helpers.GetTargetURLStr(r.Req.URL))
-		if err != nil {
-			return nil, errors.Wrap(err, "couldn't get cookies for malicious request")
-		}
-
-		for _, cookie := range cookies {
-			r.Req.AddCookie(cookie)
-		}
-	}
+	requestMeta := &types.RequestMeta{}
+	r.Req = r.Req.WithContext(withRequestMeta(ctx, requestMeta))
 
 	for header, value := range c.headers {
 		r.Req.Header.Set(header, value)
 	}
 	r.Req.Host = c.hostHeader
 
+	c.applyBrowserSession(r.Req, false)
+
 	if r.DebugHeaderValue != "" {
 		r.Req.Header.Set(clients.GTWDebugHeader, r.DebugHeaderValue)
 	}
 
-	resp, err := c.client.Do(r.Req)
+	resp, start, err := c.do(r.Req)
 	if err != nil {
 		return nil, errors.Wrap(err, "sending http request")
 	}
 
-	defer resp.Body.Close()
-
-	bodyBytes, err := io.ReadAll(resp.Body)
+	bodyBytes, truncated, err := types.ReadBody(resp, c.readLimits)
 	if err != nil {
 		return nil, errors.Wrap(err, "reading response body")
 	}
 
+	latency := time.Since(start)
+	requestMeta.Proto = resp.Proto
+
 	statusCode := resp.StatusCode
 
 	if c.followCookies && !c.renewSession && c.client.Jar != nil {
@@ -240,58 +258,56 @@ func (c *Client) SendRequest(ctx context.Context, req types.Request) (types.Resp
 		StatusReason: reason,
 		Headers:      resp.Header,
 		Content:      bodyBytes,
+		Truncated:    truncated,
+		Latency:      latency,
+		Request:      requestMeta,
 	}
 
 	return response, nil
 }
 
-func (c *Client) getCookies(ctx context.Context, targetURL string) ([]*http.Cookie, error) {
-	tr, ok := c.client.Transport.(*http.Transport)
-	if !ok {
-		return nil, errors.New("couldn't copy transport settings of the main HTTP to get cookies")
-	}
+// SetBrowserSession sets the cookies and the User-Agent obtained by the
+// browser after solving a JavaScript challenge. They will be added to all
+// subsequent requests.
+func (c *Client) SetBrowserSession(cookies []*http.Cookie, userAgent string) {
+	c.browserSession.Store(&browserSession{
+		cookies:   cookies,
+		userAgent: userAgent,
+	})
+}
 
-	jar, err := cookiejar.New(nil)
-	if err != nil {
-		return nil, errors.Wrap(err, "couldn't create cookie jar for session renewal client")
+func (c *Client) applyBrowserSession(req *http.Request, isUAPlaceholder bool) {
+	session := c.browserSession.Load()
+	if session == nil {
+		return
 	}
 
-	sessionClient := &http.Client{
-		Transport: &http.Transport{
-			DialContext:         tr.DialContext,
-			TLSClientConfig:     &tls.Config{InsecureSkipVerify: tr.TLSClientConfig.InsecureSkipVerify},
-			IdleConnTimeout:     tr.IdleConnTimeout,
-			MaxIdleConns:        tr.MaxIdleConns,
-			MaxIdleConnsPerHost: tr.MaxIdleConnsPerHost,
-			Proxy:               tr.Proxy,
-		},
-		CheckRedirect: redirectFunc,
-		Jar:           jar,
+	if session.userAgent != "" && !isUAPlaceholder {
+		req.Header.Set(placeholder.UAHeader, session.userAgent)
 	}
 
-	var returnErr error
-
-	for i := 0; i < getCookiesRepeatAttempts; i++ {
-		cookiesReq, err := http.NewRequestWithContext(ctx, "GET", targetURL, nil)
-		if err != nil {
-			returnErr = err
-			continue
-		}
+	for _, cookie := range session.cookies {
+		req.AddCookie(cookie)
+	}
+}
 
-		for header, value := range c.headers {
-			cookiesReq.Header.Set(header, value)
-		}
-		cookiesReq.Host = c.hostHeader
+// do sends the request, adding the credentials of the authentication
+// session if the authenticator is configured. The returned time is the time
+// the request was sent, the session renewal isn't included.
+func (c *Client) do(req *http.Request) (*http.Response, time.Time, error) {
+	var start time.Time
 
-		cookieResp, err := sessionClient.Do(cookiesReq)
-		if err != nil {
-			returnErr = err
-			continue
-		}
-		cookieResp.Body.Close()
+	send := func(r *http.Request) (*http.Response, error) {
+		start = time.Now()
+		return c.client.Do(r)
+	}
 
-		return sessionClient.Jar.Cookies(cookiesReq.URL), nil
+	if c.authenticator != nil {
+		resp, err := c.authenticator.Do(req, send)
+		return resp, start, err
 	}
 
-	return nil, returnErr
+	resp, err := send(req)
+
+	return resp, start, err
 }
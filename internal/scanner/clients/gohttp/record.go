@@ -0,0 +1,88 @@
+package gohttp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/wallarm/gotestwaf/internal/scanner/types"
+)
+
+type requestMetaKey struct{}
+
+// withRequestMeta returns a copy of the context in which the recordTransport
+// stores the snapshot of the sent request.
+func withRequestMeta(ctx context.Context, meta *types.RequestMeta) context.Context {
+	return context.WithValue(ctx, requestMetaKey{}, meta)
+}
+
+// recordTransport records the requests right before they are sent over the
+// wire, after the cookies, the authentication credentials and the signature
+// were added. If the request is redirected, the last request is recorded.
+type recordTransport struct {
+	base http.RoundTripper
+}
+
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	meta, ok := req.Context().Value(requestMetaKey{}).(*types.RequestMeta)
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+
+	body, err := requestBody(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't record request body")
+	}
+
+	headers := req.Header.Clone()
+	if headers == nil {
+		headers = make(http.Header)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers.Set("Host", host)
+
+	*meta = types.RequestMeta{
+		Method:  req.Method,
+		URL:     req.URL.String(),
+		Proto:   req.Proto,
+		Headers: headers,
+		Body:    body,
+	}
+
+	return t.base.RoundTrip(req)
+}
+
+// requestBody returns a copy of the request body. If the body can't be
+// obtained again, it is read and replaced with the read data.
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	if req.GetBody != nil {
+		r, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+
+		return io.ReadAll(r)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
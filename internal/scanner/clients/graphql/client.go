@@ -1,24 +1,24 @@
 package graphql
 
 import (
-	"bytes"
 	"context"
-	"crypto/tls"
 	"encoding/json"
-	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
 
+	"github.com/wallarm/gotestwaf/internal/auth"
 	"github.com/wallarm/gotestwaf/internal/config"
 	"github.com/wallarm/gotestwaf/internal/helpers"
 	"github.com/wallarm/gotestwaf/internal/payload"
 	"github.com/wallarm/gotestwaf/internal/payload/placeholder"
 	"github.com/wallarm/gotestwaf/internal/scanner/clients"
 	"github.com/wallarm/gotestwaf/internal/scanner/types"
+	"github.com/wallarm/gotestwaf/internal/signer"
 	"github.com/wallarm/gotestwaf/pkg/dnscache"
 )
 
@@ -39,15 +39,37 @@ type Client struct {
 	headers    map[string]string
 	hostHeader string
 
+	readLimits *types.ReadLimits
+
 	graphqlUrl string
 	httpUrl    string
 
 	isGraphQLAvailable bool
+
+	authenticator *auth.Authenticator
+
+	browserSession atomic.Pointer[browserSession]
+}
+
+// browserSession holds the cookies and the User-Agent of the browser which
+// has solved a JavaScript challenge.
+type browserSession struct {
+	cookies   []*http.Cookie
+	userAgent string
 }
 
-func NewClient(cfg *config.Config, dnsResolver *dnscache.Resolver) (*Client, error) {
+func NewClient(
+	cfg *config.Config,
+	dnsResolver *dnscache.Resolver,
+	authenticator *auth.Authenticator,
+) (*Client, error) {
+	tlsConf, err := helpers.NewTLSConfig(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't create TLS config")
+	}
+
 	tr := &http.Transport{
-		TLSClientConfig:     &tls.Config{InsecureSkipVerify: !cfg.TLSVerify},
+		TLSClientConfig:     tlsConf,
 		IdleConnTimeout:     time.Duration(cfg.IdleConnTimeout) * time.Second,
 		MaxIdleConns:        cfg.MaxIdleConns,
 		MaxIdleConnsPerHost: cfg.MaxIdleConns, // net.http hardcodes DefaultMaxIdleConnsPerHost to 2!
@@ -85,6 +107,17 @@ func NewClient(cfg *config.Config, dnsResolver *dnscache.Resolver) (*Client, err
 		CheckRedirect: redirectFunc,
 	}
 
+	// Requests are signed in the transport, after the payload is placed and
+	// all headers and cookies are set
+	requestSigner, err := signer.New(cfg.Signing)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't create request signer")
+	}
+
+	if requestSigner != nil {
+		client.Transport = &signer.Transport{Base: tr, Signer: requestSigner}
+	}
+
 	configuredHeaders := helpers.DeepCopyMap(cfg.HTTPHeaders)
 	customHeader := strings.SplitN(cfg.AddHeader, ":", 2)
 	if len(customHeader) > 1 {
@@ -97,11 +130,14 @@ func NewClient(cfg *config.Config, dnsResolver *dnscache.Resolver) (*Client, err
 		client:     client,
 		headers:    configuredHeaders,
 		hostHeader: configuredHeaders["Host"],
+		readLimits: types.NewReadLimits(cfg.MaxResponseSize, cfg.ResponseReadTimeout, cfg.StreamFirstEventOnly),
 
 		graphqlUrl: cfg.GraphQLURL,
 		httpUrl:    cfg.URL,
 
 		isGraphQLAvailable: true,
+
+		authenticator: authenticator,
 	}, nil
 }
 
@@ -132,13 +168,15 @@ func (c *Client) CheckAvailability(ctx context.Context) (bool, error) {
 			return false, errors.New("couldn't create request to check GraphQL availability")
 		}
 
-		resp, err := c.client.Do(req)
+		c.applyBrowserSession(req, false)
+
+		resp, _, err := c.do(req)
 		if err != nil {
 			return false, errors.New("couldn't send request to check GraphQL availability")
 		}
 
 		if resp.StatusCode == http.StatusOK {
-			bodyBytes, err := io.ReadAll(resp.Body)
+			bodyBytes, _, err := types.ReadBody(resp, c.readLimits)
 			if err != nil {
 				return false, errors.Wrap(err, "couldn't read response body")
 			}
@@ -198,23 +236,23 @@ func (c *Client) SendPayload(ctx context.Context, payloadInfo *payload.PayloadIn
 	}
 	req.Host = c.hostHeader
 
+	c.applyBrowserSession(req, isUAPlaceholder)
+
 	if payloadInfo.DebugHeaderValue != "" {
 		req.Header.Set(clients.GTWDebugHeader, payloadInfo.DebugHeaderValue)
 	}
 
-	resp, err := c.client.Do(req)
+	resp, start, err := c.do(req)
 	if err != nil {
 		return nil, errors.Wrap(err, "sending http request")
 	}
 
-	bodyBytes, err := io.ReadAll(resp.Body)
+	bodyBytes, truncated, err := types.ReadBody(resp, c.readLimits)
 	if err != nil {
 		return nil, errors.Wrap(err, "reading response body")
 	}
 
-	// body reuse
-	resp.Body.Close()
-	resp.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+	latency := time.Since(start)
 
 	statusCode := resp.StatusCode
 
@@ -226,11 +264,59 @@ func (c *Client) SendPayload(ctx context.Context, payloadInfo *payload.PayloadIn
 		StatusReason: reason,
 		Headers:      resp.Header,
 		Content:      bodyBytes,
+		Truncated:    truncated,
+		Latency:      latency,
 	}
 
 	return response, nil
 }
 
+// SetBrowserSession sets the cookies and the User-Agent obtained by the
+// browser after solving a JavaScript challenge. They will be added to all
+// subsequent requests.
+func (c *Client) SetBrowserSession(cookies []*http.Cookie, userAgent string) {
+	c.browserSession.Store(&browserSession{
+		cookies:   cookies,
+		userAgent: userAgent,
+	})
+}
+
+func (c *Client) applyBrowserSession(req *http.Request, isUAPlaceholder bool) {
+	session := c.browserSession.Load()
+	if session == nil {
+		return
+	}
+
+	if session.userAgent != "" && !isUAPlaceholder {
+		req.Header.Set(placeholder.UAHeader, session.userAgent)
+	}
+
+	for _, cookie := range session.cookies {
+		req.AddCookie(cookie)
+	}
+}
+
+// do sends the request, adding the credentials of the authentication
+// session if the authenticator is configured. The returned time is the time
+// the request was sent, the session renewal isn't included.
+func (c *Client) do(req *http.Request) (*http.Response, time.Time, error) {
+	var start time.Time
+
+	send := func(r *http.Request) (*http.Response, error) {
+		start = time.Now()
+		return c.client.Do(r)
+	}
+
+	if c.authenticator != nil {
+		resp, err := c.authenticator.Do(req, send)
+		return resp, start, err
+	}
+
+	resp, err := send(req)
+
+	return resp, start, err
+}
+
 // checkAnswer checks that answer contains "__typename" in the response body.
 // Example of correct answer:
 //
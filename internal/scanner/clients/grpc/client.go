@@ -21,8 +21,10 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
+	"github.com/wallarm/gotestwaf/internal/auth"
 	"github.com/wallarm/gotestwaf/internal/config"
 	"github.com/wallarm/gotestwaf/internal/helpers"
 	"github.com/wallarm/gotestwaf/internal/payload"
@@ -46,10 +48,15 @@ type Client struct {
 	conn *grpc.ClientConn
 
 	isAvailable bool
+
+	authenticator *auth.Authenticator
 }
 
-func NewClient(cfg *config.Config) (*Client, error) {
-	g := &Client{isAvailable: true}
+func NewClient(cfg *config.Config, authenticator *auth.Authenticator) (*Client, error) {
+	g := &Client{
+		isAvailable:   true,
+		authenticator: authenticator,
+	}
 
 	if cfg.GRPCPort == 0 {
 		g.isAvailable = false
@@ -64,7 +71,11 @@ func NewClient(cfg *config.Config) (*Client, error) {
 	g.host = host
 
 	if isTLS {
-		g.tlsConf = &tls.Config{InsecureSkipVerify: !cfg.TLSVerify}
+		g.tlsConf, err = helpers.NewTLSConfig(cfg)
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't create TLS config")
+		}
+
 		g.transportCreds = credentials.NewTLS(g.tlsConf)
 	} else {
 		g.transportCreds = insecure.NewCredentials()
@@ -200,13 +211,47 @@ func (c *Client) SendPayload(ctx context.Context, payloadInfo *payload.PayloadIn
 		}
 	}
 
+	if c.authenticator == nil {
+		return c.send(ctx, encodedPayload)
+	}
+
+	var response types.Response
+
+	err = c.authenticator.Invoke(ctx, func(headers http.Header) (int, error) {
+		callCtx := ctx
+		for header, values := range headers {
+			for _, value := range values {
+				callCtx = metadata.AppendToOutgoingContext(callCtx, strings.ToLower(header), value)
+			}
+		}
+
+		response, err = c.send(callCtx, encodedPayload)
+		if err != nil {
+			return 0, err
+		}
+
+		return response.GetStatusCode(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// send calls the test gRPC method and converts the returned gRPC status code
+// to the HTTP one.
+func (c *Client) send(ctx context.Context, encodedPayload string) (types.Response, error) {
 	client := grpcPlaceholder.NewServiceFooBarClient(c.conn)
 
 	response := &types.ResponseMeta{
 		StatusCode: 200,
 	}
 
+	start := time.Now()
+
 	resp, err := client.Foo(ctx, &grpcPlaceholder.Request{Value: encodedPayload})
+	response.Latency = time.Since(start)
 	if err != nil {
 		st := status.Convert(err)
 
@@ -0,0 +1,37 @@
+package scanner
+
+import (
+	"time"
+
+	"github.com/wallarm/gotestwaf/internal/db"
+	"github.com/wallarm/gotestwaf/internal/debugindex"
+	"github.com/wallarm/gotestwaf/internal/scanner/types"
+)
+
+// SetDebugIndexWriter enables writing of the index which maps the debug
+// header values to the tests and their classification.
+func (s *Scanner) SetDebugIndexWriter(w *debugindex.Writer) {
+	s.addResultHandler(func(info *db.Info, _ types.Response, result string, _ error) {
+		// The debug header value of the rendered payload is calculated for
+		// its template
+		payload := info.Payload
+		if info.PayloadTemplate != "" {
+			payload = info.PayloadTemplate
+		}
+
+		entry := &debugindex.Entry{
+			Hash:        debugHeaderValue(info.Set, info.Case, info.Placeholder, info.Encoder, payload),
+			Set:         info.Set,
+			Case:        info.Case,
+			Payload:     info.Payload,
+			Encoder:     info.Encoder,
+			Placeholder: info.Placeholder,
+			Timestamp:   time.Now(),
+			Result:      result,
+		}
+
+		if err := w.Add(entry); err != nil {
+			s.logger.WithError(err).Error("couldn't add the test to the debug header index")
+		}
+	})
+}
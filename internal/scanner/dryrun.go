@@ -0,0 +1,280 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/wallarm/gotestwaf/internal/config"
+	"github.com/wallarm/gotestwaf/internal/db"
+	"github.com/wallarm/gotestwaf/internal/helpers"
+	"github.com/wallarm/gotestwaf/internal/openapi"
+	p "github.com/wallarm/gotestwaf/internal/payload"
+	"github.com/wallarm/gotestwaf/internal/payload/encoder"
+	"github.com/wallarm/gotestwaf/internal/payload/placeholder"
+	"github.com/wallarm/gotestwaf/internal/payload/template"
+	"github.com/wallarm/gotestwaf/internal/scanner/clients"
+	"github.com/wallarm/gotestwaf/internal/scanner/types"
+)
+
+// DryRunCount is the number and the size of the requests built for a test
+// set, a test case, a placeholder or an encoder.
+type DryRunCount struct {
+	Requests int   `json:"requests"`
+	Bytes    int64 `json:"bytes"`
+}
+
+// DryRunStats is the result of the dry run.
+type DryRunStats struct {
+	// Tests is the number of the combinations of the payloads, the encoders
+	// and the placeholders
+	Tests    int   `json:"tests"`
+	Requests int   `json:"requests"`
+	Bytes    int64 `json:"bytes"`
+	// Failed is the number of the tests whose requests couldn't be built
+	Failed int `json:"failed"`
+	// EstimatedDuration includes only the delays between the requests, the
+	// response time isn't known without sending the requests
+	EstimatedDuration time.Duration `json:"-"`
+
+	// Cases are mapped by "<set>/<case>"
+	Sets         map[string]*DryRunCount `json:"sets"`
+	Cases        map[string]*DryRunCount `json:"cases"`
+	Placeholders map[string]*DryRunCount `json:"placeholders"`
+	Encoders     map[string]*DryRunCount `json:"encoders"`
+}
+
+func (s *DryRunStats) add(pc *payloadConfig, size int64) {
+	s.Requests++
+	s.Bytes += size
+
+	addDryRunCount(s.Sets, pc.setName, size)
+	addDryRunCount(s.Cases, pc.setName+"/"+pc.caseName, size)
+	addDryRunCount(s.Placeholders, pc.placeholder.Name, size)
+	addDryRunCount(s.Encoders, pc.encoder, size)
+}
+
+func addDryRunCount(counts map[string]*DryRunCount, key string, size int64) {
+	c, ok := counts[key]
+	if !ok {
+		c = &DryRunCount{}
+		counts[key] = c
+	}
+
+	c.Requests++
+	c.Bytes += size
+}
+
+// DryRun builds the requests of all tests as they are built by the gohttp
+// client, without sending them, and counts them. The size of the request
+// includes the request line, the headers and the body. The gRPC requests
+// can't be built, the size of the encoded payload is counted for them. The
+// GraphQL and gRPC tests are counted as if the endpoints are available. The
+// authentication and the request signing aren't applied. If dump isn't nil,
+// the built requests are written to it.
+func DryRun(
+	ctx context.Context,
+	cfg *config.Config,
+	testsDB *db.DB,
+	requestTemplates openapi.Templates,
+	dump io.Writer,
+) (*DryRunStats, error) {
+	renderer, err := template.NewRenderer(cfg.URL, cfg.TemplateVars, cfg.TemplateSeed)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't create payload template renderer")
+	}
+
+	s := &Scanner{
+		cfg:               cfg,
+		db:                testsDB,
+		renderer:          renderer,
+		requestTemplates:  requestTemplates,
+		enableDebugHeader: cfg.AddDebugHeader,
+	}
+
+	b := &dryRunBuilder{
+		s:       s,
+		headers: helpers.DeepCopyMap(cfg.HTTPHeaders),
+		dump:    dump,
+		stats: &DryRunStats{
+			Sets:         make(map[string]*DryRunCount),
+			Cases:        make(map[string]*DryRunCount),
+			Placeholders: make(map[string]*DryRunCount),
+			Encoders:     make(map[string]*DryRunCount),
+		},
+	}
+
+	if b.headers == nil {
+		b.headers = make(map[string]string)
+	}
+
+	customHeader := strings.SplitN(cfg.AddHeader, ":", 2)
+	if len(customHeader) > 1 {
+		b.headers[strings.TrimSpace(customHeader[0])] = strings.TrimSpace(customHeader[1])
+	}
+
+	for pc := range s.produceTests(ctx, 1) {
+		b.stats.Tests++
+
+		if err = b.build(ctx, pc); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Each worker waits for the delay before each test
+	workers := max(cfg.Workers, 1)
+	delay := time.Duration(cfg.SendDelay)*time.Millisecond +
+		time.Duration(max(cfg.RandomDelay-1, 0))*time.Millisecond/2
+	b.stats.EstimatedDuration = time.Duration((b.stats.Tests+workers-1)/workers) * delay
+
+	return b.stats, nil
+}
+
+// dryRunBuilder builds the requests of the tests in the dry run.
+type dryRunBuilder struct {
+	s *Scanner
+	// headers are the headers from the config added by the HTTP client
+	headers map[string]string
+	dump    io.Writer
+	stats   *DryRunStats
+}
+
+// build builds the requests of the test. The test is counted as failed if
+// its requests can't be built, as in the scan.
+func (b *dryRunBuilder) build(ctx context.Context, pc *payloadConfig) error {
+	if pc.payloadTemplate != "" {
+		key := debugHeaderValue(pc.setName, pc.caseName, pc.placeholder.Name, pc.encoder, pc.payloadTemplate)
+
+		rendered, err := b.s.renderer.Render(pc.payloadTemplate, key)
+		if err != nil {
+			b.fail(pc, err)
+			return nil
+		}
+
+		renderedConfig := *pc
+		renderedConfig.payload = rendered
+		pc = &renderedConfig
+	}
+
+	pl := &p.PayloadInfo{
+		Payload:           pc.payload,
+		EncoderName:       pc.encoder,
+		PlaceholderName:   pc.placeholder.Name,
+		PlaceholderConfig: pc.placeholder.Config,
+		DebugHeaderValue:  pc.debugHeaderValue,
+	}
+
+	if pc.placeholder.Name == placeholder.DefaultGRPC.GetName() {
+		encodedPayload, err := pl.GetEncodedPayload()
+		if err != nil {
+			b.fail(pc, err)
+			return nil
+		}
+
+		b.stats.add(pc, int64(len(encodedPayload)))
+		b.writeDump(pc, "", []byte(encodedPayload))
+
+		return nil
+	}
+
+	targetURL := b.s.cfg.URL
+
+	if pc.placeholder.Name == placeholder.DefaultGraphQL.GetName() {
+		if b.s.cfg.GraphQLURL != "" {
+			targetURL = b.s.cfg.GraphQLURL
+		}
+	} else if b.s.requestTemplates != nil {
+		encodedPayload, err := encoder.Apply(pc.encoder, pc.payload)
+		if err != nil {
+			b.fail(pc, err)
+			return nil
+		}
+
+		for _, t := range b.s.requestTemplates[pc.placeholder.Name] {
+			r, err := t.CreateRequest(ctx, pc.placeholder.Name, encodedPayload)
+			if err != nil {
+				b.fail(pc, errors.Wrap(err, "create request from template"))
+				return nil
+			}
+
+			b.add(pc, r, fmt.Sprintf("%s %s", t.Method, t.Path), false)
+		}
+	}
+
+	req, err := pl.GetRequest(targetURL, types.GoHTTPClient)
+	if err != nil {
+		b.fail(pc, err)
+		return nil
+	}
+
+	r, ok := req.(*types.GoHTTPRequest)
+	if !ok {
+		return errors.Errorf("bad request type: %T, expected %T", req, &types.GoHTTPRequest{})
+	}
+
+	b.add(pc, r.Req, "", pc.placeholder.Name == placeholder.DefaultUserAgent.GetName())
+
+	return nil
+}
+
+// add adds the headers to the request as the gohttp client and counts the
+// request.
+func (b *dryRunBuilder) add(pc *payloadConfig, req *http.Request, additionalInfo string, isUAPlaceholder bool) {
+	for header, value := range b.headers {
+		if strings.EqualFold(header, placeholder.UAHeader) && isUAPlaceholder {
+			continue
+		}
+
+		if req.Header.Get(header) == "" {
+			req.Header.Set(header, value)
+		}
+	}
+
+	if host := b.headers["Host"]; host != "" {
+		req.Host = host
+	}
+
+	if pc.debugHeaderValue != "" {
+		req.Header.Set(clients.GTWDebugHeader, pc.debugHeaderValue)
+	}
+
+	data, err := httputil.DumpRequestOut(req, true)
+	if err != nil {
+		b.fail(pc, errors.Wrap(err, "couldn't dump request"))
+		return
+	}
+
+	b.stats.add(pc, int64(len(data)))
+	b.writeDump(pc, additionalInfo, data)
+}
+
+// fail counts the test as failed and writes the error to the dump.
+func (b *dryRunBuilder) fail(pc *payloadConfig, err error) {
+	b.stats.Failed++
+	b.writeDump(pc, "", []byte("Request couldn't be built: "+err.Error()))
+}
+
+func (b *dryRunBuilder) writeDump(pc *payloadConfig, additionalInfo string, data []byte) {
+	if b.dump == nil {
+		return
+	}
+
+	fmt.Fprintf(b.dump, "=== %s/%s, %s, %s", pc.setName, pc.caseName, pc.encoder, pc.placeholder.Name)
+	if additionalInfo != "" {
+		fmt.Fprintf(b.dump, ", %s", additionalInfo)
+	}
+	fmt.Fprintf(b.dump, "\nPayload: %s\n\n", pc.payload)
+
+	b.dump.Write(data)
+	fmt.Fprint(b.dump, "\n\n")
+}
@@ -0,0 +1,92 @@
+package scanner
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wallarm/gotestwaf/internal/config"
+	"github.com/wallarm/gotestwaf/internal/db"
+)
+
+func TestDryRun(t *testing.T) {
+	testCases := []*db.Case{
+		{
+			Payloads:     []string{"<script>", "' or 1=1"},
+			Encoders:     []string{"Plain", "URL"},
+			Placeholders: []*db.Placeholder{{Name: "URLParam"}, {Name: "Header"}},
+			Set:          "owasp",
+			Name:         "xss",
+		},
+		{
+			Payloads:     []string{"union select"},
+			Encoders:     []string{"Plain"},
+			Placeholders: []*db.Placeholder{{Name: "gRPC"}},
+			Set:          "owasp-api",
+			Name:         "grpc",
+		},
+		{
+			Payloads:     []string{"{{"},
+			Encoders:     []string{"Plain"},
+			Placeholders: []*db.Placeholder{{Name: "URLParam"}},
+			Template:     true,
+			Set:          "owasp",
+			Name:         "broken",
+		},
+	}
+
+	testsDB, err := db.NewDB(testCases)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		URL:         "http://example.com/",
+		Workers:     2,
+		SendDelay:   100,
+		RandomDelay: 101,
+		AddHeader:   "X-Scan: dry",
+	}
+
+	var dump strings.Builder
+
+	stats, err := DryRun(context.Background(), cfg, testsDB, nil, &dump)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Tests != 10 || stats.Requests != 9 || stats.Failed != 1 {
+		t.Errorf("got %d tests, %d requests and %d failed, want 10, 9 and 1", stats.Tests, stats.Requests, stats.Failed)
+	}
+
+	if c := stats.Cases["owasp/xss"]; c == nil || c.Requests != 8 {
+		t.Errorf("got test case count %+v, want 8 requests", c)
+	}
+	if c := stats.Placeholders["gRPC"]; c == nil || c.Bytes != int64(len("union select")) {
+		t.Errorf("got gRPC count %+v, want the size of the payload", c)
+	}
+	if c := stats.Encoders["URL"]; c == nil || c.Requests != 4 {
+		t.Errorf("got encoder count %+v, want 4 requests", c)
+	}
+
+	var bytes int64
+	for _, c := range stats.Sets {
+		bytes += c.Bytes
+	}
+	if bytes != stats.Bytes {
+		t.Errorf("got %d bytes in the test sets, want %d", bytes, stats.Bytes)
+	}
+
+	// 5 tests per worker with the mean delay of 150ms
+	if stats.EstimatedDuration != 750*time.Millisecond {
+		t.Errorf("got estimated duration %s, want 750ms", stats.EstimatedDuration)
+	}
+
+	if n := strings.Count(dump.String(), "X-Scan: dry"); n != 8 {
+		t.Errorf("got the config header in %d dumped requests, want 8", n)
+	}
+	if !strings.Contains(dump.String(), "Request couldn't be built") {
+		t.Errorf("failed test isn't dumped")
+	}
+}
@@ -0,0 +1,42 @@
+package scanner
+
+import (
+	"github.com/wallarm/gotestwaf/internal/db"
+	"github.com/wallarm/gotestwaf/internal/har"
+	"github.com/wallarm/gotestwaf/internal/scanner/types"
+)
+
+// SetHARWriter enables recording of the test requests and the received
+// responses to the HAR file.
+func (s *Scanner) SetHARWriter(w *har.Writer) {
+	s.addResultHandler(func(info *db.Info, resp types.Response, result string, _ error) {
+		s.addHAREntry(w, info, resp, result)
+	})
+}
+
+// addHAREntry records the request and the response of the test to the HAR
+// file. Requests without a response and requests which were not recorded by
+// the HTTP client are skipped.
+func (s *Scanner) addHAREntry(w *har.Writer, info *db.Info, resp types.Response, result string) {
+	if resp == nil {
+		return
+	}
+
+	entry := har.NewEntry(resp, &har.Test{
+		Set:         info.Set,
+		Case:        info.Case,
+		Payload:     info.Payload,
+		Encoder:     info.Encoder,
+		Placeholder: info.Placeholder,
+		Type:        info.Type,
+		Result:      result,
+		Rule:        info.MatchedRule,
+	})
+	if entry == nil {
+		return
+	}
+
+	if err := w.Add(entry); err != nil {
+		s.logger.WithError(err).Error("couldn't record the request to the HAR file")
+	}
+}
@@ -0,0 +1,163 @@
+package scanner
+
+import (
+	"context"
+	"math"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/wallarm/gotestwaf/internal/db"
+	p "github.com/wallarm/gotestwaf/internal/payload"
+	"github.com/wallarm/gotestwaf/internal/payload/placeholder"
+)
+
+// Values of the tarpitDetection option.
+const (
+	// TarpitAsBlocked counts delayed test requests as blocked.
+	TarpitAsBlocked = "block"
+	// TarpitAsTarpitted counts delayed test requests as unresolved and marks
+	// them as tarpitted.
+	TarpitAsTarpitted = "tarpit"
+)
+
+const (
+	latencySamples = 10
+
+	// latencyDeviations is the number of standard deviations from the mean
+	// benign latency after which a response is considered delayed.
+	latencyDeviations = 3
+	// minAddedDelay is the minimum delay added to the mean benign latency
+	// to consider a response delayed, it filters out jitter on stable
+	// targets with a small deviation.
+	minAddedDelay = time.Second
+)
+
+// latencyBaseline describes the response time of benign requests.
+type latencyBaseline struct {
+	mean      time.Duration
+	stddev    time.Duration
+	threshold time.Duration
+}
+
+func newLatencyBaseline(samples []time.Duration) *latencyBaseline {
+	var sum float64
+	for _, d := range samples {
+		sum += float64(d)
+	}
+	mean := sum / float64(len(samples))
+
+	var variance float64
+	for _, d := range samples {
+		variance += (float64(d) - mean) * (float64(d) - mean)
+	}
+	stddev := math.Sqrt(variance / float64(len(samples)))
+
+	b := &latencyBaseline{
+		mean:   time.Duration(mean),
+		stddev: time.Duration(stddev),
+	}
+
+	addedDelay := latencyDeviations * b.stddev
+	if addedDelay < minAddedDelay {
+		addedDelay = minAddedDelay
+	}
+	b.threshold = b.mean + addedDelay
+
+	return b
+}
+
+// isDelayed checks if the latency is significantly greater than the benign
+// latency.
+func (b *latencyBaseline) isDelayed(latency time.Duration) bool {
+	if b == nil {
+		return false
+	}
+
+	return latency > b.threshold
+}
+
+// CollectLatencyBaseline sends benign requests through the placeholders used
+// by the test cases and measures the response time. Test requests with
+// significantly greater response time or timed out requests are classified
+// according to the tarpitDetection option.
+func (s *Scanner) CollectLatencyBaseline(ctx context.Context) error {
+	s.logger.WithField("status", "started").Info("Collecting benign latency")
+
+	var placeholders []*db.Placeholder
+	seen := make(map[string]struct{})
+
+	for _, testCase := range s.db.GetTestCases() {
+		for _, ph := range testCase.Placeholders {
+			if ph.Name == placeholder.DefaultGRPC.GetName() || ph.Name == placeholder.DefaultGraphQL.GetName() {
+				continue
+			}
+
+			key := placeholderKey(ph)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+
+			placeholders = append(placeholders, ph)
+		}
+	}
+
+	if len(placeholders) == 0 {
+		s.logger.Warn("There are no HTTP placeholders to measure the benign latency, tarpit detection is disabled")
+		return nil
+	}
+
+	samples := make([]time.Duration, 0, latencySamples)
+
+	for i := 0; i < latencySamples; i++ {
+		ph := placeholders[i%len(placeholders)]
+
+		pl := &p.PayloadInfo{
+			Payload:           randomWord(calibrationPayloadLength),
+			EncoderName:       "URL",
+			PlaceholderName:   ph.Name,
+			PlaceholderConfig: ph.Config,
+		}
+
+		resp, err := s.httpClient.SendPayload(ctx, s.cfg.URL, pl)
+		if err != nil {
+			return errors.Wrapf(err, "couldn't send benign request with placeholder %s", ph.Name)
+		}
+
+		samples = append(samples, resp.GetLatency())
+	}
+
+	s.latency = newLatencyBaseline(samples)
+
+	s.logger.WithFields(logrus.Fields{
+		"status":    "done",
+		"mean":      s.latency.mean,
+		"stddev":    s.latency.stddev,
+		"threshold": s.latency.threshold,
+	}).Info("Collecting benign latency")
+
+	return nil
+}
+
+// requestContext returns the context for a test request. If tarpit detection
+// is enabled, the request is limited by the tarpit timeout.
+func (s *Scanner) requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.latency == nil || s.cfg.TarpitTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, time.Duration(s.cfg.TarpitTimeout)*time.Second)
+}
+
+// isTimeout checks if the request failed because of the timeout.
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
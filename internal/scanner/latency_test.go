@@ -0,0 +1,40 @@
+package scanner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestLatencyBaseline(t *testing.T) {
+	var samples []time.Duration
+	for i := 0; i < latencySamples; i++ {
+		samples = append(samples, time.Duration(90+i*2)*time.Millisecond)
+	}
+
+	b := newLatencyBaseline(samples)
+
+	if b.threshold != b.mean+minAddedDelay {
+		t.Errorf("got threshold %s, want %s for stable latency", b.threshold, b.mean+minAddedDelay)
+	}
+	if b.isDelayed(300 * time.Millisecond) {
+		t.Errorf("300ms response is delayed")
+	}
+	if !b.isDelayed(5 * time.Second) {
+		t.Errorf("5s response isn't delayed")
+	}
+
+	var noBaseline *latencyBaseline
+	if noBaseline.isDelayed(time.Hour) {
+		t.Errorf("response is delayed without the baseline")
+	}
+
+	if !isTimeout(errors.Wrap(context.DeadlineExceeded, "sending http request")) {
+		t.Errorf("deadline exceeded isn't a timeout")
+	}
+	if isTimeout(errors.Wrap(context.Canceled, "sending http request")) {
+		t.Errorf("canceled request is a timeout")
+	}
+}
@@ -0,0 +1,185 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/wallarm/gotestwaf/internal/db"
+	"github.com/wallarm/gotestwaf/internal/scanner/types"
+)
+
+var debugHashRegex = regexp.MustCompile(`^[[:xdigit:]]{64}$`)
+
+// replayTest is a test found by the debug header hash or by the payload
+// index.
+type replayTest struct {
+	*payloadConfig
+
+	payloadIndex int
+}
+
+// Replay sends the tests selected by the debug header hash or by
+// "<set>/<case>/<payload index>" once and writes the requests, the responses
+// and their classification to w. The payload index is zero-based, all
+// combinations of encoders and placeholders are sent for the payload.
+func (s *Scanner) Replay(ctx context.Context, test string, w io.Writer) error {
+	defer s.grpcConn.Close()
+
+	tests, err := s.findReplayTests(test)
+	if err != nil {
+		return err
+	}
+
+	var sent int
+	s.addResultHandler(func(info *db.Info, resp types.Response, result string, sendErr error) {
+		sent++
+		dumpExchange(w, info, resp, result, sendErr)
+	})
+
+	for _, t := range tests {
+		fmt.Fprintf(w, "=== %s/%s, payload %d\n", t.setName, t.caseName, t.payloadIndex)
+		fmt.Fprintf(w, "Payload:     %s\n", t.payload)
+		fmt.Fprintf(w, "Encoder:     %s\n", t.encoder)
+		fmt.Fprintf(w, "Placeholder: %s\n", t.placeholder.Name)
+		fmt.Fprintf(w, "Type:        %s\n", t.testType)
+		fmt.Fprintf(w, "Debug hash:  %s\n\n", t.debugHeaderValue)
+
+		sent = 0
+		if err = s.sendPayload(ctx, t.payloadConfig); err != nil {
+			return errors.Wrap(err, "couldn't send the test")
+		}
+
+		// gRPC and GraphQL tests are skipped if the endpoint isn't available
+		if sent == 0 {
+			fmt.Fprintf(w, "The test wasn't sent, the %s endpoint isn't available\n\n", t.placeholder.Name)
+		}
+	}
+
+	return nil
+}
+
+// findReplayTests finds the tests by the debug header hash or by
+// "<set>/<case>/<payload index>". The debug header is always added to the
+// found tests.
+func (s *Scanner) findReplayTests(test string) ([]*replayTest, error) {
+	var (
+		hash               string
+		setName, caseName  string
+		payloadIndex       int
+		matchPayloadByHash bool
+	)
+
+	if debugHashRegex.MatchString(test) {
+		hash = strings.ToLower(test)
+		matchPayloadByHash = true
+	} else {
+		parts := strings.Split(test, "/")
+		if len(parts) != 3 {
+			return nil, errors.Errorf("test must be a debug header hash or <set>/<case>/<payload index>, got %q", test)
+		}
+
+		var err error
+		payloadIndex, err = strconv.Atoi(parts[2])
+		if err != nil || payloadIndex < 0 {
+			return nil, errors.Errorf("invalid payload index: %s", parts[2])
+		}
+
+		setName, caseName = parts[0], parts[1]
+	}
+
+	var tests []*replayTest
+
+	for _, testCase := range s.db.GetTestCases() {
+		if !matchPayloadByHash && (testCase.Set != setName || testCase.Name != caseName) {
+			continue
+		}
+
+		for i, payload := range testCase.Payloads {
+			if !matchPayloadByHash && i != payloadIndex {
+				continue
+			}
+
+			for _, encoder := range testCase.Encoders {
+				for _, placeholder := range testCase.Placeholders {
+					if !testCase.Selected(encoder, placeholder.Name) {
+						continue
+					}
+
+					pc := newPayloadConfig(testCase, payload, encoder, placeholder, true)
+					if matchPayloadByHash && pc.debugHeaderValue != hash {
+						continue
+					}
+
+					tests = append(tests, &replayTest{payloadConfig: pc, payloadIndex: i})
+				}
+			}
+		}
+	}
+
+	if len(tests) == 0 {
+		return nil, errors.Errorf("test %s not found in the test cases", test)
+	}
+
+	return tests, nil
+}
+
+// dumpExchange writes the request, the response and the classification of
+// the test.
+func dumpExchange(w io.Writer, info *db.Info, resp types.Response, result string, sendErr error) {
+	if resp == nil {
+		fmt.Fprintf(w, "Request failed: %v\n", sendErr)
+		fmt.Fprintf(w, "Result: %s\n\n", result)
+		return
+	}
+
+	if req := resp.GetRequest(); req != nil {
+		fmt.Fprintf(w, "> %s %s %s\n", req.Method, req.URL, req.Proto)
+		dumpHeaders(w, "> ", req.Headers)
+		fmt.Fprintln(w, ">")
+		if len(req.Body) > 0 {
+			fmt.Fprintf(w, "%s\n", req.Body)
+		}
+		fmt.Fprintln(w)
+	} else {
+		fmt.Fprintf(w, "The request of the %s placeholder isn't recorded\n\n", info.Placeholder)
+	}
+
+	fmt.Fprintf(w, "< %d %s\n", resp.GetStatusCode(), resp.GetReason())
+	dumpHeaders(w, "< ", resp.GetHeaders())
+	fmt.Fprintln(w, "<")
+	if content := resp.GetContent(); len(content) > 0 {
+		fmt.Fprintf(w, "%s\n", content)
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "Result: %s\n", result)
+	if info.MatchedRule != "" {
+		fmt.Fprintf(w, "Rule: %s\n", info.MatchedRule)
+	}
+	if len(info.BlockedBy) > 0 {
+		fmt.Fprintf(w, "Blocked by: %s\n", strings.Join(info.BlockedBy, ", "))
+	}
+	fmt.Fprintf(w, "Response time: %s\n\n", info.Latency)
+}
+
+func dumpHeaders(w io.Writer, prefix string, headers http.Header) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range headers[name] {
+			fmt.Fprintf(w, "%s%s: %s\n", prefix, name, value)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wallarm/gotestwaf/internal/db"
+)
+
+func TestFindReplayTests(t *testing.T) {
+	testCase := &db.Case{
+		Payloads:     []string{"<script>", "' or 1=1"},
+		Encoders:     []string{"Plain", "URL"},
+		Placeholders: []*db.Placeholder{{Name: "URLParam"}, {Name: "Header"}},
+		Set:          "owasp",
+		Name:         "xss",
+	}
+
+	testsDB, err := db.NewDB([]*db.Case{testCase})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Scanner{db: testsDB}
+
+	hash := debugHeaderValue("owasp", "xss", "Header", "URL", "' or 1=1")
+
+	tests, err := s.findReplayTests(strings.ToUpper(hash))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tests) != 1 {
+		t.Fatalf("got %d tests, want 1", len(tests))
+	}
+	if pc := tests[0]; pc.payloadIndex != 1 || pc.encoder != "URL" || pc.placeholder.Name != "Header" || pc.debugHeaderValue != hash {
+		t.Errorf("got test %+v with payload index %d", pc.payloadConfig, pc.payloadIndex)
+	}
+
+	tests, err = s.findReplayTests("owasp/xss/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tests) != 4 {
+		t.Fatalf("got %d tests, want all combinations of encoders and placeholders", len(tests))
+	}
+	for _, pc := range tests {
+		if pc.payload != "<script>" || pc.debugHeaderValue == "" {
+			t.Errorf("got test %+v", pc.payloadConfig)
+		}
+	}
+
+	for _, test := range []string{
+		strings.Repeat("0", 64),
+		"owasp/xss/2",
+		"owasp/sqli/0",
+		"owasp/xss/-1",
+		"owasp/xss",
+	} {
+		if _, err = s.findReplayTests(test); err == nil {
+			t.Errorf("test %s is found", test)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/wallarm/gotestwaf/internal/db"
+	"github.com/wallarm/gotestwaf/internal/scanner/types"
+)
+
+// Classification of the test requests passed to the result handlers.
+const (
+	resultBlocked     = "blocked"
+	resultPassed      = "passed"
+	resultUnresolved  = "unresolved"
+	resultAppRejected = "app rejected"
+	resultTarpitted   = "tarpitted"
+	resultFailed      = "failed"
+)
+
+// resultHandler is called for each test request after the response is
+// classified. The response is nil if the request wasn't sent.
+type resultHandler func(info *db.Info, resp types.Response, result string, sendErr error)
+
+func (s *Scanner) addResultHandler(h resultHandler) {
+	s.resultHandlers = append(s.resultHandlers, h)
+}
+
+// debugHeaderValue returns the hash of the test information which is sent
+// in the X-GoTestWAF-Test header.
+func debugHeaderValue(setName, caseName, placeholderName, encoderName, payload string) string {
+	hash := sha256.New()
+
+	hash.Write([]byte(setName))
+	hash.Write([]byte(caseName))
+	hash.Write([]byte(placeholderName))
+	hash.Write([]byte(encoderName))
+	hash.Write([]byte(payload))
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// newPayloadConfig creates the test with the payload, the encoder and the
+// placeholder of the test case.
+func newPayloadConfig(testCase *db.Case, payload, encoder string, placeholder *db.Placeholder, withDebugHeader bool) *payloadConfig {
+	pc := &payloadConfig{
+		payload:     payload,
+		encoder:     encoder,
+		placeholder: placeholder,
+
+		setName:        testCase.Set,
+		caseName:       testCase.Name,
+		testType:       testCase.Type,
+		isTruePositive: testCase.IsTruePositive,
+		category:       testCase.Category,
+		metadata:       testCase.Metadata,
+	}
+
+	if testCase.Template {
+		pc.payloadTemplate = payload
+	}
+
+	if withDebugHeader {
+		pc.debugHeaderValue = debugHeaderValue(testCase.Set, testCase.Name, placeholder.Name, encoder, payload)
+	}
+
+	return pc
+}
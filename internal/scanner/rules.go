@@ -0,0 +1,245 @@
+package scanner
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/wallarm/gotestwaf/internal/config"
+	"github.com/wallarm/gotestwaf/internal/scanner/types"
+)
+
+const (
+	ruleActionBlock = "block"
+	ruleActionPass  = "pass"
+)
+
+// responseMatcher checks if the response matches a rule condition.
+type responseMatcher func(resp types.Response) bool
+
+// rule is a compiled config.ResponseRule.
+type rule struct {
+	name    string
+	blocked bool
+	match   responseMatcher
+}
+
+// compileRules compiles the response rules from the config.
+func compileRules(cfgRules []*config.ResponseRule) ([]*rule, error) {
+	rules := make([]*rule, 0, len(cfgRules))
+	names := make(map[string]struct{}, len(cfgRules))
+
+	for i, r := range cfgRules {
+		if r == nil || r.Name == "" {
+			return nil, errors.Errorf("rule #%d: empty rule name", i+1)
+		}
+
+		if _, ok := names[r.Name]; ok {
+			return nil, errors.Errorf("duplicate rule name %q", r.Name)
+		}
+		names[r.Name] = struct{}{}
+
+		var blocked bool
+		switch strings.ToLower(r.Action) {
+		case ruleActionBlock:
+			blocked = true
+		case ruleActionPass:
+			blocked = false
+		default:
+			return nil, errors.Errorf("rule %q: unknown action %q, expected block or pass", r.Name, r.Action)
+		}
+
+		if r.Match == nil {
+			return nil, errors.Errorf("rule %q: empty match condition", r.Name)
+		}
+
+		match, err := compileCondition(r.Match)
+		if err != nil {
+			return nil, errors.Wrapf(err, "rule %q", r.Name)
+		}
+
+		rules = append(rules, &rule{
+			name:    r.Name,
+			blocked: blocked,
+			match:   match,
+		})
+	}
+
+	return rules, nil
+}
+
+func compileCondition(c *config.RuleCondition) (responseMatcher, error) {
+	var (
+		matchers []responseMatcher
+		kinds    []string
+	)
+
+	if len(c.And) != 0 {
+		kinds = append(kinds, "and")
+
+		subMatchers, err := compileConditions(c.And)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, func(resp types.Response) bool {
+			for _, m := range subMatchers {
+				if !m(resp) {
+					return false
+				}
+			}
+			return true
+		})
+	}
+
+	if len(c.Or) != 0 {
+		kinds = append(kinds, "or")
+
+		subMatchers, err := compileConditions(c.Or)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, func(resp types.Response) bool {
+			for _, m := range subMatchers {
+				if m(resp) {
+					return true
+				}
+			}
+			return false
+		})
+	}
+
+	if c.Not != nil {
+		kinds = append(kinds, "not")
+
+		subMatcher, err := compileCondition(c.Not)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, func(resp types.Response) bool {
+			return !subMatcher(resp)
+		})
+	}
+
+	if len(c.Status) != 0 {
+		kinds = append(kinds, "status")
+
+		codes := c.Status
+		matchers = append(matchers, func(resp types.Response) bool {
+			for _, code := range codes {
+				if resp.GetStatusCode() == code {
+					return true
+				}
+			}
+			return false
+		})
+	}
+
+	if c.Header != nil {
+		kinds = append(kinds, "header")
+
+		if c.Header.Name == "" {
+			return nil, errors.New("empty header name")
+		}
+
+		re, err := regexp.Compile(c.Header.Regex)
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't compile header regex")
+		}
+
+		name := http.CanonicalHeaderKey(c.Header.Name)
+		matchers = append(matchers, func(resp types.Response) bool {
+			for _, value := range resp.GetHeaders()[name] {
+				if re.MatchString(value) {
+					return true
+				}
+			}
+			return false
+		})
+	}
+
+	if c.Body != "" {
+		kinds = append(kinds, "body")
+
+		re, err := regexp.Compile(c.Body)
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't compile body regex")
+		}
+
+		matchers = append(matchers, func(resp types.Response) bool {
+			return re.Match(resp.GetContent())
+		})
+	}
+
+	if c.BodySize != nil {
+		kinds = append(kinds, "bodySize")
+
+		r := *c.BodySize
+		if r.Max != 0 && r.Max < r.Min {
+			return nil, errors.Errorf("bad body size range: min %d is greater than max %d", r.Min, r.Max)
+		}
+
+		matchers = append(matchers, func(resp types.Response) bool {
+			size := len(resp.GetContent())
+			return size >= r.Min && (r.Max == 0 || size <= r.Max)
+		})
+	}
+
+	if c.ResponseTime != nil {
+		kinds = append(kinds, "responseTime")
+
+		r := *c.ResponseTime
+		if r.Max != 0 && r.Max < r.Min {
+			return nil, errors.Errorf("bad response time range: min %s is greater than max %s", r.Min, r.Max)
+		}
+
+		matchers = append(matchers, func(resp types.Response) bool {
+			latency := resp.GetLatency()
+			return latency >= r.Min && (r.Max == 0 || latency <= r.Max)
+		})
+	}
+
+	if len(matchers) != 1 {
+		if len(matchers) == 0 {
+			return nil, errors.New("empty condition")
+		}
+
+		return nil, errors.Errorf("condition must contain exactly one check, got: %s", strings.Join(kinds, ", "))
+	}
+
+	return matchers[0], nil
+}
+
+func compileConditions(conditions []*config.RuleCondition) ([]responseMatcher, error) {
+	if len(conditions) == 0 {
+		return nil, errors.New("empty list of conditions")
+	}
+
+	matchers := make([]responseMatcher, 0, len(conditions))
+	for _, c := range conditions {
+		if c == nil {
+			return nil, errors.New("empty condition")
+		}
+
+		m, err := compileCondition(c)
+		if err != nil {
+			return nil, err
+		}
+
+		matchers = append(matchers, m)
+	}
+
+	return matchers, nil
+}
+
+// matchRule returns the first rule matched by the response or nil.
+func (s *Scanner) matchRule(resp types.Response) *rule {
+	for _, r := range s.rules {
+		if r.match(resp) {
+			return r
+		}
+	}
+
+	return nil
+}
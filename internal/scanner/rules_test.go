@@ -0,0 +1,107 @@
+package scanner
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/wallarm/gotestwaf/internal/config"
+	"github.com/wallarm/gotestwaf/internal/scanner/types"
+)
+
+func TestRules(t *testing.T) {
+	rules, err := compileRules([]*config.ResponseRule{
+		{
+			Name:   "waf-header",
+			Action: "block",
+			Match: &config.RuleCondition{
+				And: []*config.RuleCondition{
+					{Status: []int{403}},
+					{Header: &config.RuleHeader{Name: "x-blocked-by", Regex: "waf"}},
+				},
+			},
+		},
+		{
+			Name:   "rejected-page",
+			Action: "block",
+			Match: &config.RuleCondition{
+				And: []*config.RuleCondition{
+					{Status: []int{200}},
+					{Body: "<title>[^<]*Request rejected"},
+				},
+			},
+		},
+		{
+			Name:   "fast-and-small",
+			Action: "pass",
+			Match: &config.RuleCondition{
+				And: []*config.RuleCondition{
+					{BodySize: &config.RuleSizeRange{Max: 16}},
+					{Not: &config.RuleCondition{ResponseTime: &config.RuleDurationRange{Min: time.Second}}},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("couldn't compile rules: %v", err)
+	}
+
+	s := &Scanner{rules: rules}
+
+	testCases := []struct {
+		name string
+		resp types.Response
+		rule string
+	}{
+		{
+			name: "403 with header",
+			resp: &types.ResponseMeta{
+				StatusCode: 403,
+				Headers:    http.Header{"X-Blocked-By": []string{"waf"}},
+			},
+			rule: "waf-header",
+		},
+		{
+			name: "403 without header",
+			resp: &types.ResponseMeta{StatusCode: 403, Content: []byte("<html><title>Forbidden</title></html>")},
+		},
+		{
+			name: "200 with title",
+			resp: newTestResponse(200, "<html><title>Error: Request rejected</title></html>"),
+			rule: "rejected-page",
+		},
+		{
+			name: "small fast response",
+			resp: &types.ResponseMeta{StatusCode: 200, Content: []byte("OK"), Latency: time.Millisecond},
+			rule: "fast-and-small",
+		},
+		{
+			name: "small slow response",
+			resp: &types.ResponseMeta{StatusCode: 200, Content: []byte("OK"), Latency: 2 * time.Second},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var name string
+			if r := s.matchRule(tc.resp); r != nil {
+				name = r.name
+			}
+
+			if name != tc.rule {
+				t.Errorf("got rule %q, want %q", name, tc.rule)
+			}
+		})
+	}
+
+	_, err = compileRules([]*config.ResponseRule{
+		{
+			Name:   "ambiguous",
+			Action: "block",
+			Match:  &config.RuleCondition{Status: []int{403}, Body: "blocked"},
+		},
+	})
+	if err == nil {
+		t.Errorf("condition with two checks is compiled")
+	}
+}
@@ -3,8 +3,6 @@ package scanner
 import (
 	"bytes"
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
 	"io"
 	"math/rand"
@@ -26,6 +24,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc/metadata"
 
+	"github.com/wallarm/gotestwaf/internal/auth"
 	"github.com/wallarm/gotestwaf/internal/config"
 	"github.com/wallarm/gotestwaf/internal/db"
 	dns_cache "github.com/wallarm/gotestwaf/internal/dnscache"
@@ -34,6 +33,7 @@ import (
 	p "github.com/wallarm/gotestwaf/internal/payload"
 	"github.com/wallarm/gotestwaf/internal/payload/encoder"
 	"github.com/wallarm/gotestwaf/internal/payload/placeholder"
+	"github.com/wallarm/gotestwaf/internal/payload/template"
 	"github.com/wallarm/gotestwaf/internal/scanner/clients"
 	"github.com/wallarm/gotestwaf/internal/scanner/clients/chrome"
 	"github.com/wallarm/gotestwaf/internal/scanner/clients/gohttp"
@@ -52,7 +52,11 @@ var jsChallengeErrorMsgs = []string{
 }
 
 type payloadConfig struct {
-	payload     string
+	payload string
+	// payloadTemplate is set if the payload is rendered from the template
+	// before sending, the debug header value is calculated for the template
+	payloadTemplate string
+
 	encoder     string
 	placeholder *db.Placeholder
 
@@ -60,6 +64,8 @@ type payloadConfig struct {
 	caseName       string
 	testType       string
 	isTruePositive bool
+	category       string
+	metadata       db.Metadata
 
 	debugHeaderValue string
 }
@@ -81,6 +87,24 @@ type Scanner struct {
 	grpcConn      clients.GRPCClient
 	graphqlClient clients.GraphQLClient
 
+	challengeSolver *challengeSolver
+
+	// calibration is set by Calibrate
+	calibration *calibration
+	// baselines are set by CollectBaselines
+	baselines map[string]*baseline
+	// latency is set by CollectLatencyBaseline
+	latency *latencyBaseline
+
+	// rules are compiled response rules from the config
+	rules []*rule
+
+	// renderer renders the payloads of the test cases with templates
+	renderer *template.Renderer
+
+	// resultHandlers are called for each test request
+	resultHandlers []resultHandler
+
 	requestTemplates openapi.Templates
 	router           routers.Router
 
@@ -107,25 +131,65 @@ func New(
 		return nil, errors.Wrap(err, "couldn't create DNS cache")
 	}
 
+	authenticator, err := auth.New(cfg, dnsCache)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't create authenticator")
+	}
+
+	rules, err := compileRules(cfg.Rules)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't compile response rules")
+	}
+
+	var gohttpClient *gohttp.Client
+
 	if cfg.HTTPClient == "chrome" {
+		if cfg.Auth != nil {
+			logger.Warn("The authentication flow is not applied to requests sent by the chrome client")
+		}
+		if cfg.Signing != nil {
+			logger.Warn("Requests sent by the chrome client are not signed")
+		}
+
 		httpClient, err = chrome.NewClient(cfg)
 	} else {
-		httpClient, err = gohttp.NewClient(cfg, dnsCache)
+		gohttpClient, err = gohttp.NewClient(cfg, dnsCache, authenticator)
+		httpClient = gohttpClient
 	}
 	if err != nil {
 		return nil, errors.Wrap(err, "couldn't create HTTP client")
 	}
 
-	grpcConn, err := grpc.NewClient(cfg)
+	// The implicit flow of the --followCookies and --renewSession options
+	// renews the cookies of the gohttp client only
+	apiAuthenticator := authenticator
+	if cfg.Auth == nil {
+		apiAuthenticator = nil
+	}
+
+	grpcConn, err := grpc.NewClient(cfg, apiAuthenticator)
 	if err != nil {
 		return nil, errors.Wrap(err, "couldn't create gRPC client")
 	}
 
-	graphqlClient, err := graphql.NewClient(cfg, dnsCache)
+	graphqlClient, err := graphql.NewClient(cfg, dnsCache, apiAuthenticator)
 	if err != nil {
 		return nil, errors.Wrap(err, "couldn't create GraphQL client")
 	}
 
+	var solver *challengeSolver
+	if gohttpClient != nil && cfg.SolveJSChallenge {
+		solver, err = newChallengeSolver(logger, cfg, gohttpClient, graphqlClient)
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't create JavaScript challenge solver")
+		}
+	}
+
+	renderer, err := template.NewRenderer(cfg.URL, cfg.TemplateVars, cfg.TemplateSeed)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't create payload template renderer")
+	}
+
 	return &Scanner{
 		logger:            logger,
 		cfg:               cfg,
@@ -133,6 +197,9 @@ func New(
 		httpClient:        httpClient,
 		grpcConn:          grpcConn,
 		graphqlClient:     graphqlClient,
+		challengeSolver:   solver,
+		rules:             rules,
+		renderer:          renderer,
 		requestTemplates:  requestTemplates,
 		router:            router,
 		enableDebugHeader: enableDebugHeader,
@@ -153,7 +220,12 @@ func (s *Scanner) CheckIfJavaScriptRequired(ctx context.Context) (bool, error) {
 	cfgFixed.FollowCookies = true
 	cfgFixed.RenewSession = true
 
-	client, err := gohttp.NewClient(&cfgFixed, nil)
+	authenticator, err := auth.New(&cfgFixed, nil)
+	if err != nil {
+		return false, err
+	}
+
+	client, err := gohttp.NewClient(&cfgFixed, nil, authenticator)
 	if err != nil {
 		return false, err
 	}
@@ -163,15 +235,35 @@ func (s *Scanner) CheckIfJavaScriptRequired(ctx context.Context) (bool, error) {
 		return false, err
 	}
 
-	body := string(resp.GetContent())
+	return isJSChallenge(string(resp.GetContent())), nil
+}
+
+// SolveJSChallenge solves the JavaScript challenge in a headless Chrome and
+// passes the browser session to the HTTP client. It requires the
+// --solveJSChallenge option.
+func (s *Scanner) SolveJSChallenge(ctx context.Context) error {
+	if s.challengeSolver == nil {
+		return errors.New("JavaScript challenge solving is not enabled")
+	}
+
+	return s.challengeSolver.Solve(ctx)
+}
+
+// Close releases resources held by the HTTP clients, e.g. stops the browser.
+func (s *Scanner) Close() error {
+	var err error
+
+	if closer, ok := s.httpClient.(io.Closer); ok {
+		err = closer.Close()
+	}
 
-	for i := range jsChallengeErrorMsgs {
-		if strings.Contains(body, jsChallengeErrorMsgs[i]) {
-			return true, nil
+	if s.challengeSolver != nil {
+		if closeErr := s.challengeSolver.Close(); closeErr != nil {
+			err = closeErr
 		}
 	}
 
-	return false, nil
+	return err
 }
 
 // CheckGRPCAvailability checks if the gRPC server is available at the given URL.
@@ -372,7 +464,7 @@ func (s *Scanner) preCheck(ctx context.Context, payload string) (blocked bool, s
 		return false, 0, err
 	}
 
-	blocked, _, err = s.checkBlockedOrPassed(resp)
+	blocked, _, _, _, err = s.checkBlockedOrPassed(&db.Placeholder{Name: pl.PlaceholderName}, resp)
 	if err != nil {
 		return false, 0, err
 	}
@@ -382,13 +474,21 @@ func (s *Scanner) preCheck(ctx context.Context, payload string) (blocked bool, s
 
 // checkBlockedOrPassed checks the response status-code or request body using
 // a regular expression to determine if the request has been blocked or passed.
+// If the placeholder was calibrated, the response is classified by similarity
+// to the learned responses instead of the status codes, and the confidence of
+// the decision is returned. The response rules from the config take precedence
+// over all other checks, the name of the matched rule is returned.
 func (s *Scanner) checkBlockedOrPassed(
+	ph *db.Placeholder,
 	resp types.Response,
-) (blocked, passed bool, err error) {
-	if s.cfg.CheckBlockFunc != nil {
-		if s.cfg.CheckBlockFunc(&detectors.Responses{RespToAttack: resp}) {
-			return true, false, nil
-		}
+) (blocked, passed bool, confidence float64, ruleName string, err error) {
+	// rules set by the user are checked first
+	if r := s.matchRule(resp); r != nil {
+		return r.blocked, !r.blocked, 0, r.name, nil
+	}
+
+	if s.blockedBySignature(resp) {
+		return true, false, 0, "", nil
 	}
 
 	var headers []string
@@ -429,6 +529,13 @@ func (s *Scanner) checkBlockedOrPassed(
 		}
 	}
 
+	// regular expressions set by the user take precedence
+	if !blocked && !passed {
+		if isBlocked, conf, ok := s.calibration.classify(ph, resp); ok {
+			return isBlocked, !isBlocked, conf, "", nil
+		}
+	}
+
 	for _, code := range s.cfg.BlockStatusCodes {
 		if statusCode == code {
 			blocked = true
@@ -444,6 +551,26 @@ func (s *Scanner) checkBlockedOrPassed(
 	return
 }
 
+// blockedBySignature checks if the response matches the signature of the
+// identified WAF.
+func (s *Scanner) blockedBySignature(resp types.Response) bool {
+	return s.cfg.CheckBlockFunc != nil && s.cfg.CheckBlockFunc(&detectors.Responses{RespToAttack: resp})
+}
+
+// blockedBy returns the names of the identified WAFs whose block page
+// signatures match the response.
+func (s *Scanner) blockedBy(resp types.Response) []string {
+	var layers []string
+
+	for _, d := range s.cfg.WAFLayers {
+		if d.IsBlockPage(resp) {
+			layers = append(layers, d.WAFName)
+		}
+	}
+
+	return layers
+}
+
 // produceTests generates all combinations of payload, encoder, and placeholder
 // for n goroutines.
 func (s *Scanner) produceTests(ctx context.Context, n int) <-chan *payloadConfig {
@@ -453,40 +580,15 @@ func (s *Scanner) produceTests(ctx context.Context, n int) <-chan *payloadConfig
 	go func() {
 		defer close(payloadChan)
 
-		var debugHeaderValue string
-
-		hash := sha256.New()
-
 		for _, testCase := range testCases {
 			for _, payload := range testCase.Payloads {
 				for _, encoder := range testCase.Encoders {
 					for _, placeholder := range testCase.Placeholders {
-						if s.enableDebugHeader {
-							hash.Reset()
-
-							hash.Write([]byte(testCase.Set))
-							hash.Write([]byte(testCase.Name))
-							hash.Write([]byte(placeholder.Name))
-							hash.Write([]byte(encoder))
-							hash.Write([]byte(payload))
-
-							debugHeaderValue = hex.EncodeToString(hash.Sum(nil))
-						} else {
-							debugHeaderValue = ""
+						if !testCase.Selected(encoder, placeholder.Name) {
+							continue
 						}
 
-						wrk := &payloadConfig{
-							payload:     payload,
-							encoder:     encoder,
-							placeholder: placeholder,
-
-							setName:        testCase.Set,
-							caseName:       testCase.Name,
-							testType:       testCase.Type,
-							isTruePositive: testCase.IsTruePositive,
-
-							debugHeaderValue: debugHeaderValue,
-						}
+						wrk := newPayloadConfig(testCase, payload, encoder, placeholder, s.enableDebugHeader)
 
 						select {
 						case payloadChan <- wrk:
@@ -506,6 +608,20 @@ func (s *Scanner) produceTests(ctx context.Context, n int) <-chan *payloadConfig
 func (s *Scanner) sendPayload(ctx context.Context, pc *payloadConfig) error {
 	var err error
 
+	if pc.payloadTemplate != "" {
+		key := debugHeaderValue(pc.setName, pc.caseName, pc.placeholder.Name, pc.encoder, pc.payloadTemplate)
+
+		rendered, renderErr := s.renderer.Render(pc.payloadTemplate, key)
+		if renderErr != nil {
+			// The test is counted as failed
+			return s.updateDB(ctx, pc, &testStatus{}, nil, nil, renderErr, renderErr.Error(), false)
+		}
+
+		renderedConfig := *pc
+		renderedConfig.payload = rendered
+		pc = &renderedConfig
+	}
+
 	if pc.placeholder.Name == placeholder.DefaultGRPC.GetName() {
 		return s.sendGrpcRequest(ctx, pc)
 	}
@@ -546,7 +662,9 @@ func (s *Scanner) sendGrpcRequest(ctx context.Context, pc *payloadConfig) error
 		EncoderName: pc.encoder,
 	}
 
-	resp, err := s.grpcConn.SendPayload(newCtx, pl)
+	sendCtx, cancel := s.requestContext(newCtx)
+	resp, err := s.grpcConn.SendPayload(sendCtx, pl)
+	cancel()
 
 	err = s.updateDB(ctx, pc, &testStatus{}, nil, resp, err, "", true)
 
@@ -573,16 +691,60 @@ func (s *Scanner) sendGraphQLRequest(ctx context.Context, pc *payloadConfig) err
 		DebugHeaderValue:  pc.debugHeaderValue,
 	}
 
-	resp, err = s.graphqlClient.SendPayload(ctx, pl)
-	if err == nil && resp != nil {
-		err = resp.GetError()
-	}
+	resp, err = s.sendHTTP(ctx, func(sendCtx context.Context) (types.Response, error) {
+		return s.graphqlClient.SendPayload(sendCtx, pl)
+	})
 
 	err = s.updateDB(ctx, pc, &testStatus{}, nil, resp, err, "", false)
 
 	return err
 }
 
+// sendHTTP sends a test request over HTTP. If the response is the JavaScript
+// challenge page and the browser session has expired, the challenge is solved
+// again and the request is resent. The send function must create a new
+// request for each call.
+func (s *Scanner) sendHTTP(
+	ctx context.Context,
+	send func(sendCtx context.Context) (types.Response, error),
+) (types.Response, error) {
+	var generation uint64
+	if s.challengeSolver != nil {
+		generation = s.challengeSolver.Generation()
+	}
+
+	resp, err := s.sendWithTimeout(ctx, send)
+
+	if err == nil && s.challengeSolver != nil && isJSChallenge(string(resp.GetContent())) {
+		resend, renewErr := s.challengeSolver.Renew(ctx, generation)
+		if renewErr != nil {
+			s.logger.WithError(renewErr).Error("couldn't renew the browser session")
+		}
+
+		if resend {
+			resp, err = s.sendWithTimeout(ctx, send)
+		}
+	}
+
+	return resp, err
+}
+
+// sendWithTimeout calls the send function with the test request context.
+func (s *Scanner) sendWithTimeout(
+	ctx context.Context,
+	send func(sendCtx context.Context) (types.Response, error),
+) (types.Response, error) {
+	sendCtx, cancel := s.requestContext(ctx)
+	defer cancel()
+
+	resp, err := send(sendCtx)
+	if err == nil && resp != nil {
+		err = resp.GetError()
+	}
+
+	return resp, err
+}
+
 // sendRequest sends an HTTP request with the provided payload configuration.
 func (s *Scanner) sendRequest(ctx context.Context, pc *payloadConfig) error {
 	var (
@@ -598,10 +760,9 @@ func (s *Scanner) sendRequest(ctx context.Context, pc *payloadConfig) error {
 		DebugHeaderValue:  pc.debugHeaderValue,
 	}
 
-	resp, err = s.httpClient.SendPayload(ctx, s.cfg.URL, pl)
-	if err == nil && resp != nil {
-		err = resp.GetError()
-	}
+	resp, err = s.sendHTTP(ctx, func(sendCtx context.Context) (types.Response, error) {
+		return s.httpClient.SendPayload(sendCtx, s.cfg.URL, pl)
+	})
 
 	err = s.updateDB(ctx, pc, &testStatus{}, nil, resp, err, "", false)
 
@@ -630,13 +791,23 @@ func (s *Scanner) sendOpenAPIRequests(ctx context.Context, pc *payloadConfig) er
 	ts := &testStatus{}
 
 	for _, template := range templates {
-		r, err = template.CreateRequest(ctx, pc.placeholder.Name, encodedPayload)
-		if err != nil {
-			return errors.Wrap(err, "create request from template")
-		}
+		var createErr error
+
+		resp, err = s.sendHTTP(ctx, func(sendCtx context.Context) (types.Response, error) {
+			// The request is created for each attempt, the body of the sent
+			// request can't be read again
+			r, createErr = template.CreateRequest(ctx, pc.placeholder.Name, encodedPayload)
+			if createErr != nil {
+				return nil, createErr
+			}
+
+			req = &types.GoHTTPRequest{Req: r}
 
-		req = &types.GoHTTPRequest{Req: r}
-		resp, err = s.httpClient.SendRequest(ctx, req)
+			return s.httpClient.SendRequest(sendCtx, req)
+		})
+		if createErr != nil {
+			return errors.Wrap(createErr, "create request from template")
+		}
 
 		additionalInfo = fmt.Sprintf("%s %s", template.Method, template.Path)
 
@@ -664,13 +835,30 @@ func (s *Scanner) updateDB(
 	isGRPC bool,
 ) (err error) {
 	info := payloadConfig.toInfo(resp)
+	if s.cfg.IncludeEvidence && resp != nil {
+		info.Evidence = db.NewEvidence(resp.GetHeaders(), resp.GetContent(), s.cfg.EvidenceBodySize)
+	}
 
-	var blockedByReset bool
+	var result string
+	defer func() {
+		// The result isn't set if the response couldn't be classified
+		if result == "" {
+			return
+		}
+
+		for _, h := range s.resultHandlers {
+			h(info, resp, result, sendErr)
+		}
+	}()
+
+	var blockedByReset, timedOut bool
 	if sendErr != nil {
 		if errors.Is(sendErr, io.EOF) || errors.Is(sendErr, syscall.ECONNRESET) {
 			if s.cfg.BlockConnReset {
 				blockedByReset = true
 			} else {
+				result = resultUnresolved
+
 				if ts.unresolvedTest == nil {
 					ts.unresolvedTest = info
 					s.db.UpdateNaTests(ts.unresolvedTest, s.cfg.IgnoreUnresolved, s.cfg.NonBlockedAsPassed, payloadConfig.isTruePositive)
@@ -681,7 +869,12 @@ func (s *Scanner) updateDB(
 
 				return
 			}
+		} else if s.latency != nil && isTimeout(sendErr) {
+			timedOut = true
+			info.Latency = time.Duration(s.cfg.TarpitTimeout) * time.Second
 		} else {
+			result = resultFailed
+
 			if ts.failedTest == nil {
 				ts.failedTest = info
 				s.db.UpdateFailedTests(ts.failedTest)
@@ -699,13 +892,75 @@ func (s *Scanner) updateDB(
 	var blocked, passed bool
 	if blockedByReset {
 		blocked = true
-	} else {
-		blocked, passed, err = s.checkBlockedOrPassed(resp)
+	} else if !timedOut {
+		blocked, passed, info.Confidence, info.MatchedRule, err = s.checkBlockedOrPassed(payloadConfig.placeholder, resp)
 		if err != nil {
 			return errors.Wrap(err, "failed to check blocking")
 		}
 	}
 
+	if blocked && resp != nil {
+		info.BlockedBy = s.blockedBy(resp)
+	}
+
+	// The response rules set by the user take precedence over the delay
+	if !blocked && info.MatchedRule == "" && (timedOut || s.latency.isDelayed(info.Latency)) {
+		info.Tarpitted = true
+		result = resultTarpitted
+
+		if s.cfg.TarpitDetection == TarpitAsBlocked {
+			result = resultBlocked
+
+			if ts.blockedTest == nil {
+				ts.blockedTest = info
+				s.db.UpdateBlockedTests(ts.blockedTest)
+			}
+			if len(additionalInfo) != 0 {
+				ts.blockedTest.AdditionalInfo = append(ts.blockedTest.AdditionalInfo, additionalInfo)
+			}
+		} else {
+			if ts.unresolvedTest == nil {
+				ts.unresolvedTest = info
+				s.db.UpdateNaTests(ts.unresolvedTest, s.cfg.IgnoreUnresolved, s.cfg.NonBlockedAsPassed, payloadConfig.isTruePositive)
+			}
+			if len(additionalInfo) != 0 {
+				ts.unresolvedTest.AdditionalInfo = append(ts.unresolvedTest.AdditionalInfo, additionalInfo)
+			}
+		}
+
+		return
+	}
+
+	// The decision of a response rule or the WAF signature isn't overridden
+	// by the similarity to the baseline response
+	decided := info.MatchedRule != "" || (blocked && s.blockedBySignature(resp))
+
+	if !blockedByReset && !decided {
+		if b := s.getBaseline(payloadConfig.placeholder, additionalInfo); b != nil && b.matches(resp) {
+			if b.rejected {
+				// The application rejected the request shape as it did for the
+				// benign payload, so the WAF decision is unknown
+				info.AppRejected = true
+				result = resultAppRejected
+
+				if ts.unresolvedTest == nil {
+					ts.unresolvedTest = info
+					s.db.UpdateNaTests(ts.unresolvedTest, s.cfg.IgnoreUnresolved, s.cfg.NonBlockedAsPassed, payloadConfig.isTruePositive)
+				}
+				if len(additionalInfo) != 0 {
+					ts.unresolvedTest.AdditionalInfo = append(ts.unresolvedTest.AdditionalInfo, additionalInfo)
+				}
+
+				return
+			}
+
+			// The response is the same as for the benign payload
+			if !blocked && !passed {
+				passed = true
+			}
+		}
+	}
+
 	if s.requestTemplates != nil && !isGRPC {
 		r, ok := req.(*types.GoHTTPRequest)
 		if !ok {
@@ -745,6 +1000,8 @@ func (s *Scanner) updateDB(
 		}
 
 		if validationErr := openapi3filter.ValidateResponse(ctx, responseValidationInput); validationErr == nil && !blocked {
+			result = resultPassed
+
 			if ts.passedTest == nil {
 				ts.passedTest = info
 				s.db.UpdatePassedTests(ts.passedTest)
@@ -753,6 +1010,8 @@ func (s *Scanner) updateDB(
 				ts.passedTest.AdditionalInfo = append(ts.passedTest.AdditionalInfo, additionalInfo)
 			}
 		} else {
+			result = resultBlocked
+
 			if ts.blockedTest == nil {
 				ts.blockedTest = info
 				s.db.UpdateBlockedTests(ts.blockedTest)
@@ -766,6 +1025,8 @@ func (s *Scanner) updateDB(
 	}
 
 	if (blocked && passed) || (!blocked && !passed) {
+		result = resultUnresolved
+
 		if ts.unresolvedTest == nil {
 			ts.unresolvedTest = info
 			s.db.UpdateNaTests(ts.unresolvedTest, s.cfg.IgnoreUnresolved, s.cfg.NonBlockedAsPassed, payloadConfig.isTruePositive)
@@ -775,6 +1036,8 @@ func (s *Scanner) updateDB(
 		}
 	} else {
 		if blocked {
+			result = resultBlocked
+
 			if ts.blockedTest == nil {
 				ts.blockedTest = info
 				s.db.UpdateBlockedTests(ts.blockedTest)
@@ -783,6 +1046,8 @@ func (s *Scanner) updateDB(
 				ts.blockedTest.AdditionalInfo = append(ts.blockedTest.AdditionalInfo, additionalInfo)
 			}
 		} else {
+			result = resultPassed
+
 			if ts.passedTest == nil {
 				ts.passedTest = info
 				s.db.UpdatePassedTests(ts.passedTest)
@@ -804,10 +1069,20 @@ func (pc *payloadConfig) toInfo(resp types.Response) *db.Info {
 		Encoder:     pc.encoder,
 		Placeholder: pc.placeholder.Name,
 		Type:        pc.testType,
+		Metadata:    pc.metadata,
+
+		PayloadTemplate: pc.payloadTemplate,
+		Expect:          db.ExpectBlock,
+		Category:        pc.category,
+	}
+
+	if !pc.isTruePositive {
+		info.Expect = db.ExpectPass
 	}
 
 	if resp != nil {
 		info.ResponseStatusCode = resp.GetStatusCode()
+		info.Latency = resp.GetLatency()
 	}
 
 	return info
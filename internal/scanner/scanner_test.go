@@ -0,0 +1,89 @@
+package scanner
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/wallarm/gotestwaf/internal/config"
+	"github.com/wallarm/gotestwaf/internal/scanner/types"
+	"github.com/wallarm/gotestwaf/internal/scanner/waf_detector/detectors"
+)
+
+func TestBlockedBy(t *testing.T) {
+	// The CDN headers are added to any response, only the CDN block page
+	// attributes the block to the CDN
+	cdn := &detectors.Detector{
+		WAFName: "CDN",
+		Check: detectors.Or(
+			detectors.CheckHeader("Server", "^cdn", false),
+			detectors.And(
+				detectors.CheckStatusCode(403, true),
+				detectors.CheckContent("CDN error", true),
+			),
+		),
+	}
+	waf := &detectors.Detector{
+		WAFName: "WAF",
+		Check: detectors.And(
+			detectors.CheckContent("Access denied", true),
+			detectors.Not(detectors.CheckHeader("Server", "^cdn", false)),
+		),
+	}
+
+	s := &Scanner{
+		cfg: &config.Config{
+			WAFLayers: []*detectors.Detector{cdn, waf},
+		},
+	}
+
+	blockPage := func(body string) types.Response {
+		return &types.ResponseMeta{
+			StatusCode: 403,
+			Headers:    http.Header{"Server": {"cdn"}},
+			Content:    []byte(body),
+		}
+	}
+
+	got := s.blockedBy(blockPage("Access denied"))
+	if want := []string{"WAF"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("blocked by %v, want %v", got, want)
+	}
+
+	got = s.blockedBy(blockPage("CDN error"))
+	if want := []string{"CDN"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("blocked by %v, want %v", got, want)
+	}
+}
+
+func TestSendHTTPRenewedSession(t *testing.T) {
+	solver := &challengeSolver{}
+	s := &Scanner{
+		cfg:             &config.Config{},
+		challengeSolver: solver,
+	}
+
+	sent := 0
+	resp, err := s.sendHTTP(context.Background(), func(context.Context) (types.Response, error) {
+		sent++
+
+		// The session is renewed by another request while this one is sent
+		if sent == 1 {
+			solver.generation.Add(1)
+			return &types.ResponseMeta{Content: []byte(jsChallengeErrorMsgs[0])}, nil
+		}
+
+		return &types.ResponseMeta{Content: []byte("OK")}, nil
+	})
+	if err != nil {
+		t.Fatalf("couldn't send request: %v", err)
+	}
+
+	if sent != 2 {
+		t.Errorf("request is sent %d times, want 2", sent)
+	}
+	if string(resp.GetContent()) != "OK" {
+		t.Errorf("got %q, want the response to the resent request", resp.GetContent())
+	}
+}
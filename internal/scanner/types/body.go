@@ -0,0 +1,161 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+)
+
+const readChunkSize = 32 * 1024
+
+// streamDelimiters contains the delimiters of the first message for the
+// streaming content types.
+var streamDelimiters = map[string][][]byte{
+	"text/event-stream":    {[]byte("\n\n"), []byte("\r\n\r\n"), []byte("\r\r")},
+	"application/x-ndjson": {[]byte("\n")},
+	"application/jsonl":    {[]byte("\n")},
+}
+
+// ReadLimits restricts reading of the response body. Streaming responses
+// (SSE, long polling, chunked JSON lines) may never end, so only the initial
+// portion of the body is read and used to detect blocking.
+type ReadLimits struct {
+	// MaxBytes is the maximum number of bytes to read, 0 means no limit.
+	MaxBytes int64
+	// MaxDuration is the maximum time to read the body, 0 means no limit.
+	MaxDuration time.Duration
+	// FirstEventOnly stops reading after the first event of a
+	// text/event-stream response or the first line of a JSON lines response.
+	FirstEventOnly bool
+}
+
+// NewReadLimits creates ReadLimits from the config values: the maximum body
+// size in bytes and the maximum read time in seconds.
+func NewReadLimits(maxBytes int, maxDurationSec int, firstEventOnly bool) *ReadLimits {
+	return &ReadLimits{
+		MaxBytes:       int64(maxBytes),
+		MaxDuration:    time.Duration(maxDurationSec) * time.Second,
+		FirstEventOnly: firstEventOnly,
+	}
+}
+
+// ReadBody reads the response body according to the limits. It returns the
+// read data and true if the body was read partially. The response body is
+// replaced with the read data, so it can be read again.
+func ReadBody(resp *http.Response, limits *ReadLimits) (body []byte, truncated bool, err error) {
+	src := resp.Body
+	timedOut := false
+
+	defer func() {
+		if timedOut {
+			// The reader is still blocked in Read, and Close of the HTTP/1
+			// body waits for it, so the body is closed in the background
+			go src.Close()
+		} else {
+			src.Close()
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}()
+
+	if limits == nil {
+		limits = &ReadLimits{}
+	}
+
+	var delimiters [][]byte
+	if limits.FirstEventOnly {
+		mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		delimiters = streamDelimiters[mediaType]
+	}
+
+	var deadline <-chan time.Time
+	if limits.MaxDuration > 0 {
+		timer := time.NewTimer(limits.MaxDuration)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	// Chunks are read in a separate goroutine on demand, so the read can be
+	// interrupted by the deadline
+	next := make(chan struct{})
+	defer close(next)
+	chunks := make(chan readResult, 1)
+	go readChunks(src, next, chunks)
+
+	var buf bytes.Buffer
+
+	for {
+		next <- struct{}{}
+
+		var res readResult
+		select {
+		case res = <-chunks:
+		case <-deadline:
+			timedOut = true
+			return buf.Bytes(), true, nil
+		}
+
+		buf.Write(res.data)
+
+		if limits.MaxBytes > 0 && int64(buf.Len()) > limits.MaxBytes {
+			return buf.Bytes()[:limits.MaxBytes], true, nil
+		}
+
+		if end := firstMessageEnd(buf.Bytes(), delimiters); end != -1 {
+			return buf.Bytes()[:end], true, nil
+		}
+
+		if res.err != nil {
+			if errors.Is(res.err, io.EOF) {
+				return buf.Bytes(), false, nil
+			}
+
+			return buf.Bytes(), false, res.err
+		}
+	}
+}
+
+type readResult struct {
+	data []byte
+	err  error
+}
+
+// readChunks reads a chunk from r each time a value is received from next.
+func readChunks(r io.Reader, next <-chan struct{}, chunks chan<- readResult) {
+	chunk := make([]byte, readChunkSize)
+
+	for range next {
+		n, err := r.Read(chunk)
+		chunks <- readResult{data: bytes.Clone(chunk[:n]), err: err}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// firstMessageEnd returns the end position of the first non-empty message
+// including the delimiter or -1 if the message is incomplete.
+func firstMessageEnd(data []byte, delimiters [][]byte) int {
+	if len(delimiters) == 0 {
+		return -1
+	}
+
+	// skip leading empty lines
+	start := len(data) - len(bytes.TrimLeft(data, "\r\n"))
+
+	end := -1
+	for _, delimiter := range delimiters {
+		i := bytes.Index(data[start:], delimiter)
+		if i == -1 {
+			continue
+		}
+
+		if pos := start + i + len(delimiter); end == -1 || pos < end {
+			end = pos
+		}
+	}
+
+	return end
+}
@@ -0,0 +1,98 @@
+package types
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReadBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher := w.(http.Flusher)
+
+		if r.URL.Path == "/plain" {
+			fmt.Fprint(w, "0123456789")
+			return
+		}
+
+		w.Header().Set("Content-Type", r.URL.Query().Get("ct"))
+
+		// endless stream
+		for i := 0; ; i++ {
+			var err error
+			if r.URL.Path == "/sse" {
+				_, err = fmt.Fprintf(w, "\nevent: message\ndata: %d\n\n", i)
+			} else {
+				_, err = fmt.Fprintf(w, "{\"id\":%d}\n", i)
+			}
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(50 * time.Millisecond):
+			}
+		}
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		path      string
+		limits    *ReadLimits
+		want      string
+		truncated bool
+	}{
+		{path: "/plain", limits: nil, want: "0123456789"},
+		{path: "/plain", limits: &ReadLimits{MaxBytes: 4}, want: "0123", truncated: true},
+		{path: "/plain", limits: &ReadLimits{MaxBytes: 10}, want: "0123456789"},
+		{
+			path:      "/sse?ct=text/event-stream%3B+charset%3Dutf-8",
+			limits:    &ReadLimits{FirstEventOnly: true},
+			want:      "\nevent: message\ndata: 0\n\n",
+			truncated: true,
+		},
+		{
+			path:      "/jsonl?ct=application/x-ndjson",
+			limits:    &ReadLimits{FirstEventOnly: true},
+			want:      "{\"id\":0}\n",
+			truncated: true,
+		},
+		{
+			path:      "/jsonl?ct=application/x-ndjson",
+			limits:    &ReadLimits{MaxDuration: 120 * time.Millisecond},
+			want:      "{\"id\":0}\n{\"id\":1}\n{\"id\":2}\n",
+			truncated: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		resp, err := http.Get(server.URL + tc.path)
+		if err != nil {
+			t.Fatalf("%s: couldn't send request: %v", tc.path, err)
+		}
+
+		body, truncated, err := ReadBody(resp, tc.limits)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.path, err)
+		}
+
+		if string(body) != tc.want {
+			t.Errorf("%s: got body %q, want %q", tc.path, body, tc.want)
+		}
+		if truncated != tc.truncated {
+			t.Errorf("%s: got truncated %v, want %v", tc.path, truncated, tc.truncated)
+		}
+
+		// the body must be readable again
+		again, _ := io.ReadAll(resp.Body)
+		if string(again) != string(body) {
+			t.Errorf("%s: body wasn't replaced", tc.path)
+		}
+	}
+}
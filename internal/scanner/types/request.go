@@ -37,3 +37,13 @@ type ChromeDPTasks struct {
 }
 
 func (r *ChromeDPTasks) IsRequest() {}
+
+// RequestMeta is a snapshot of the request sent to the target, it contains
+// the headers and the body as they were sent over the wire.
+type RequestMeta struct {
+	Method  string
+	URL     string
+	Proto   string
+	Headers http.Header
+	Body    []byte
+}
@@ -1,11 +1,10 @@
 package types
 
 import (
-	"bytes"
 	"errors"
-	"io"
 	"net/http"
 	"strings"
+	"time"
 )
 
 var _ Response = (*GoHTTPResponse)(nil)
@@ -28,12 +27,29 @@ type Response interface {
 
 	// GetError returns any error that occurred during the request processing.
 	GetError() error
+
+	// GetLatency returns the time elapsed from sending the request until the
+	// response body was read. It is zero if the latency wasn't measured.
+	GetLatency() time.Duration
+
+	// GetRequest returns the request sent to the target as it was recorded
+	// by the client. It is nil if the client doesn't record requests.
+	GetRequest() *RequestMeta
 }
 
 // GoHTTPResponse is a wrapper that provides implementation of the Response
 // interface for the *http.Response.
 type GoHTTPResponse struct {
 	Resp *http.Response
+
+	// Limits restricts reading of the response body.
+	Limits *ReadLimits
+
+	// Latency is the response time measured by the sender.
+	Latency time.Duration
+
+	// Request is the request recorded by the sender.
+	Request *RequestMeta
 }
 
 func (r *GoHTTPResponse) GetStatusCode() int {
@@ -51,15 +67,12 @@ func (r *GoHTTPResponse) GetHeaders() http.Header {
 }
 
 func (r *GoHTTPResponse) GetContent() []byte {
-	body, err := io.ReadAll(r.Resp.Body)
+	// the body is replaced with the read data, so it can be read again
+	body, _, err := ReadBody(r.Resp, r.Limits)
 	if err != nil {
 		return nil
 	}
 
-	// body reuse
-	r.Resp.Body.Close()
-	r.Resp.Body = io.NopCloser(bytes.NewReader(body))
-
 	return body
 }
 
@@ -67,6 +80,14 @@ func (r *GoHTTPResponse) GetError() error {
 	return nil
 }
 
+func (r *GoHTTPResponse) GetLatency() time.Duration {
+	return r.Latency
+}
+
+func (r *GoHTTPResponse) GetRequest() *RequestMeta {
+	return r.Request
+}
+
 // ResponseMeta provides implementation information about response performed with
 // Chrome HTTP client
 type ResponseMeta struct {
@@ -75,6 +96,18 @@ type ResponseMeta struct {
 	Headers      http.Header
 	Content      []byte
 	Error        string
+
+	// Truncated is true if only the initial portion of the response body
+	// was read because of the read limits.
+	Truncated bool
+
+	// Latency is the time elapsed from sending the request until the
+	// response body was read.
+	Latency time.Duration
+
+	// Request is the request sent to the target, it is set by the clients
+	// which record requests.
+	Request *RequestMeta
 }
 
 func (r *ResponseMeta) GetStatusCode() int {
@@ -100,3 +133,11 @@ func (r *ResponseMeta) GetError() error {
 
 	return nil
 }
+
+func (r *ResponseMeta) GetLatency() time.Duration {
+	return r.Latency
+}
+
+func (r *ResponseMeta) GetRequest() *RequestMeta {
+	return r.Request
+}
@@ -35,11 +35,13 @@ type WAFDetector struct {
 	headers        map[string]string
 	hostHeader     string
 	target         string
+	readLimits     *types.ReadLimits
+	detectors      []*detectors.Detector
 }
 
 type ClientSettings struct {
 	dnsResolver         *dnscache.Resolver
-	insecureSkipVerify  bool
+	tlsConfig           *tls.Config
 	idleConnTimeout     time.Duration
 	maxIdleConns        int
 	maxIdleConnsPerHost int
@@ -47,8 +49,13 @@ type ClientSettings struct {
 }
 
 func NewWAFDetector(logger *logrus.Logger, cfg *config.Config) (*WAFDetector, error) {
+	tlsConfig, err := helpers.NewTLSConfig(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't create TLS config")
+	}
+
 	clientSettings := &ClientSettings{
-		insecureSkipVerify:  !cfg.TLSVerify,
+		tlsConfig:           tlsConfig,
 		idleConnTimeout:     time.Duration(cfg.IdleConnTimeout) * time.Second,
 		maxIdleConns:        cfg.MaxIdleConns,
 		maxIdleConnsPerHost: cfg.MaxIdleConns,
@@ -75,6 +82,11 @@ func NewWAFDetector(logger *logrus.Logger, cfg *config.Config) (*WAFDetector, er
 		return nil, errors.Wrap(err, "couldn't parse URL")
 	}
 
+	wafDetectors, err := detectors.LoadDetectors(cfg.WAFDetectorsPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't load WAF detectors")
+	}
+
 	configuredHeaders := cfg.HTTPHeaders
 	customHeader := strings.SplitN(cfg.AddHeader, ":", 2)
 	if len(customHeader) > 1 {
@@ -88,13 +100,15 @@ func NewWAFDetector(logger *logrus.Logger, cfg *config.Config) (*WAFDetector, er
 		headers:        configuredHeaders,
 		hostHeader:     configuredHeaders["Host"],
 		target:         helpers.GetTargetURLStr(target),
+		readLimits:     types.NewReadLimits(cfg.MaxResponseSize, cfg.ResponseReadTimeout, cfg.StreamFirstEventOnly),
+		detectors:      wafDetectors,
 	}, nil
 }
 
 func (w *WAFDetector) getHttpClient() (*http.Client, error) {
 	tr := &http.Transport{
 		DialContext:         dnscache.DialFunc(w.clientSettings.dnsResolver, nil),
-		TLSClientConfig:     &tls.Config{InsecureSkipVerify: w.clientSettings.insecureSkipVerify},
+		TLSClientConfig:     w.clientSettings.tlsConfig.Clone(),
 		IdleConnTimeout:     w.clientSettings.idleConnTimeout,
 		MaxIdleConns:        w.clientSettings.maxIdleConns,
 		MaxIdleConnsPerHost: w.clientSettings.maxIdleConns, // net.http hardcodes DefaultMaxIdleConnsPerHost to 2!
@@ -176,33 +190,47 @@ func (w *WAFDetector) doMaliciousRequest(ctx context.Context) (*http.Response, e
 	return resp, nil
 }
 
-// DetectWAF performs WAF identification. Returns WAF name and vendor after
-// the first positive match.
-func (w *WAFDetector) DetectWAF(ctx context.Context) (name, vendor string, checkFunc detectors.Check, err error) {
+// Match is a WAF identified by a detector.
+type Match struct {
+	Detector *detectors.Detector
+
+	// Checks contains descriptions of the conditions that matched
+	Checks []string
+}
+
+// DetectWAF performs WAF identification. The traffic may pass several WAFs,
+// e.g. a CDN WAF and an on-premise one, so all detectors are evaluated and
+// every match is returned in the order of the detectors.
+func (w *WAFDetector) DetectWAF(ctx context.Context) ([]*Match, error) {
 	resp, err := w.doRequest(ctx)
 	if err != nil {
-		return "", "", nil, errors.Wrap(err, "couldn't perform request without attack")
+		return nil, errors.Wrap(err, "couldn't perform request without attack")
 	}
 
 	defer resp.Body.Close()
 
 	respToAttack, err := w.doMaliciousRequest(ctx)
 	if err != nil {
-		return "", "", nil, errors.Wrap(err, "couldn't perform request with attack")
+		return nil, errors.Wrap(err, "couldn't perform request with attack")
 	}
 
 	defer respToAttack.Body.Close()
 
 	resps := &detectors.Responses{
-		Resp:         &types.GoHTTPResponse{Resp: resp},
-		RespToAttack: &types.GoHTTPResponse{Resp: respToAttack},
+		Resp:         &types.GoHTTPResponse{Resp: resp, Limits: w.readLimits},
+		RespToAttack: &types.GoHTTPResponse{Resp: respToAttack, Limits: w.readLimits},
 	}
 
-	for _, d := range detectors.Detectors {
-		if d.IsWAF(resps) {
-			return d.WAFName, d.Vendor, d.Check, nil
+	var matches []*Match
+
+	for _, d := range w.detectors {
+		if ok, checks := d.Explain(resps); ok {
+			matches = append(matches, &Match{
+				Detector: d,
+				Checks:   checks,
+			})
 		}
 	}
 
-	return "", "", nil, nil
+	return matches, nil
 }
@@ -1,6 +1,7 @@
 package detectors
 
 import (
+	"fmt"
 	"regexp"
 
 	"github.com/wallarm/gotestwaf/internal/scanner/types"
@@ -9,6 +10,64 @@ import (
 type Responses struct {
 	Resp         types.Response
 	RespToAttack types.Response
+
+	// Trace collects descriptions of the conditions that made the check
+	// true. It is not collected if nil.
+	Trace *[]string
+
+	// AttackOnly makes the conditions of the benign response unknown, so
+	// the check is decided by the conditions of the attack response only.
+	// The unknown conditions are skipped by And and Or, and Not of an
+	// unknown condition is unknown.
+	AttackOnly bool
+
+	// unknown is true if the result of the last performed check is unknown
+	unknown bool
+}
+
+// response returns the response checked by the condition. The condition is
+// unknown if it checks the benign response in the attack only mode.
+func (r *Responses) response(attack bool) (types.Response, bool) {
+	r.unknown = r.AttackOnly && !attack
+	if r.unknown {
+		return nil, false
+	}
+
+	if attack {
+		return r.RespToAttack, true
+	}
+
+	return r.Resp, true
+}
+
+// record adds the description of the matched condition to the trace.
+func (r *Responses) record(format string, attack bool, args ...any) {
+	if r.Trace == nil {
+		return
+	}
+
+	response := "benign response"
+	if attack {
+		response = "attack response"
+	}
+
+	*r.Trace = append(*r.Trace, fmt.Sprintf(format, args...)+" in "+response)
+}
+
+// traceLen returns the current length of the trace.
+func (r *Responses) traceLen() int {
+	if r.Trace == nil {
+		return 0
+	}
+
+	return len(*r.Trace)
+}
+
+// truncateTrace removes the conditions recorded after the trace had length n.
+func (r *Responses) truncateTrace(n int) {
+	if r.Trace != nil {
+		*r.Trace = (*r.Trace)[:n]
+	}
 }
 
 // Check performs some check on the response with a fixed condition.
@@ -18,16 +77,13 @@ type Check func(resps *Responses) bool
 // Default value for attack parameter is true.
 func CheckStatusCode(status int, attack bool) Check {
 	f := func(resps *Responses) bool {
-		resp := resps.Resp
-		if attack {
-			resp = resps.RespToAttack
-		}
-
-		if resp == nil {
+		resp, known := resps.response(attack)
+		if !known || resp == nil {
 			return false
 		}
 
 		if resp.GetStatusCode() == status {
+			resps.record("status code is %d", attack, status)
 			return true
 		}
 
@@ -43,16 +99,13 @@ func CheckReason(regex string, attack bool) Check {
 	re := regexp.MustCompile(regex)
 
 	f := func(resps *Responses) bool {
-		resp := resps.Resp
-		if attack {
-			resp = resps.RespToAttack
-		}
-
-		if resp == nil {
+		resp, known := resps.response(attack)
+		if !known || resp == nil {
 			return false
 		}
 
 		if re.MatchString(resp.GetReason()) {
+			resps.record("reason matches %q", attack, regex)
 			return true
 		}
 
@@ -68,12 +121,8 @@ func CheckHeader(header, regex string, attack bool) Check {
 	re := regexp.MustCompile(regex)
 
 	f := func(resps *Responses) bool {
-		resp := resps.Resp
-		if attack {
-			resp = resps.RespToAttack
-		}
-
-		if resp == nil {
+		resp, known := resps.response(attack)
+		if !known || resp == nil {
 			return false
 		}
 
@@ -85,6 +134,7 @@ func CheckHeader(header, regex string, attack bool) Check {
 
 		for i := range values {
 			if re.MatchString(values[i]) {
+				resps.record("header %s matches %q", attack, header, regex)
 				return true
 			}
 		}
@@ -107,12 +157,8 @@ func CheckContent(regex string, attack bool) Check {
 	re := regexp.MustCompile(regex)
 
 	f := func(resps *Responses) bool {
-		resp := resps.Resp
-		if attack {
-			resp = resps.RespToAttack
-		}
-
-		if resp == nil {
+		resp, known := resps.response(attack)
+		if !known || resp == nil {
 			return false
 		}
 
@@ -122,6 +168,7 @@ func CheckContent(regex string, attack bool) Check {
 		}
 
 		if re.Match(body) {
+			resps.record("body matches %q", attack, regex)
 			return true
 		}
 
@@ -135,13 +182,26 @@ func CheckContent(regex string, attack bool) Check {
 // so each test must be true to return true.
 func And(checks ...Check) Check {
 	f := func(resps *Responses) bool {
+		n := resps.traceLen()
+		known := false
+
 		for _, check := range checks {
-			if !check(resps) {
+			ok := check(resps)
+			if resps.unknown {
+				continue
+			}
+
+			known = true
+
+			if !ok {
+				resps.truncateTrace(n)
 				return false
 			}
 		}
 
-		return true
+		resps.unknown = !known
+
+		return known
 	}
 
 	return f
@@ -151,14 +211,40 @@ func And(checks ...Check) Check {
 // so at least one test must be true to return true.
 func Or(checks ...Check) Check {
 	f := func(resps *Responses) bool {
+		known := false
+
 		for _, check := range checks {
 			if check(resps) {
 				return true
 			}
+
+			if !resps.unknown {
+				known = true
+			}
 		}
 
+		resps.unknown = !known
+
 		return false
 	}
 
 	return f
 }
+
+// Not inverts the result of the check.
+func Not(check Check) Check {
+	f := func(resps *Responses) bool {
+		// conditions matched by the inverted check don't explain the result
+		n := resps.traceLen()
+		defer resps.truncateTrace(n)
+
+		ok := check(resps)
+		if resps.unknown {
+			return false
+		}
+
+		return !ok
+	}
+
+	return f
+}
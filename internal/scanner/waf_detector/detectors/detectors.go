@@ -1,5 +1,9 @@
 package detectors
 
+import (
+	"github.com/wallarm/gotestwaf/internal/scanner/types"
+)
+
 // Detector contains names of WAF solution and vendor, and checks to detect that
 // solution by response.
 type Detector struct {
@@ -21,6 +25,32 @@ func (d *Detector) IsWAF(resps *Responses) bool {
 	return d.Check(resps)
 }
 
+// IsBlockPage performs the check on the response to the blocked request.
+// Only the conditions of the attack response are taken into account, so the
+// conditions which identify the WAF by any response, e.g. the headers of a
+// CDN, don't attribute the block to the WAF.
+func (d *Detector) IsBlockPage(resp types.Response) bool {
+	return d.Check(&Responses{RespToAttack: resp, AttackOnly: true})
+}
+
+// Explain performs the check and returns descriptions of the conditions that
+// made it true.
+func (d *Detector) Explain(resps *Responses) (bool, []string) {
+	var trace []string
+
+	traced := &Responses{
+		Resp:         resps.Resp,
+		RespToAttack: resps.RespToAttack,
+		Trace:        &trace,
+	}
+
+	if !d.Check(traced) {
+		return false, nil
+	}
+
+	return true, trace
+}
+
 // Detectors is the list of all available WAF detectors. The checks are performed
 // in the given order.
 var Detectors = []*Detector{
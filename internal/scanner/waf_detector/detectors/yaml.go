@@ -0,0 +1,295 @@
+package detectors
+
+import (
+	"embed"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// definitions contains the built-in detectors in YAML format.
+//
+//go:embed definitions/*.yaml
+var definitions embed.FS
+
+// yamlDetector is a detector definition in YAML format:
+//
+//	name: Cloudflare
+//	vendor: Cloudflare Inc.
+//	check:
+//	  or:
+//	    - header: {name: Server, regex: cloudflare}
+//	    - and:
+//	        - status: 403
+//	        - body: Cloudflare Ray ID
+type yamlDetector struct {
+	Name   string     `yaml:"name"`
+	Vendor string     `yaml:"vendor"`
+	Check  *yamlCheck `yaml:"check"`
+}
+
+// yamlCheck is a node of the check tree. It must contain exactly one
+// condition or one of the and, or and not operators.
+type yamlCheck struct {
+	And []*yamlCheck `yaml:"and"`
+	Or  []*yamlCheck `yaml:"or"`
+	Not *yamlCheck   `yaml:"not"`
+
+	Status int         `yaml:"status"`
+	Reason string      `yaml:"reason"`
+	Header *yamlHeader `yaml:"header"`
+	Cookie string      `yaml:"cookie"`
+	Body   string      `yaml:"body"`
+
+	// Response selects the response to check: "attack" or "benign". By
+	// default, status, reason and body are checked in the response to the
+	// malicious request, header and cookie in the response to the benign one.
+	Response string `yaml:"response"`
+}
+
+type yamlHeader struct {
+	Name  string `yaml:"name"`
+	Regex string `yaml:"regex"`
+}
+
+// ParseDetector parses the detector definition in YAML format.
+func ParseDetector(data []byte) (*Detector, error) {
+	var d yamlDetector
+	if err := yaml.UnmarshalStrict(data, &d); err != nil {
+		return nil, err
+	}
+
+	if d.Name == "" {
+		return nil, errors.New("empty detector name")
+	}
+
+	if d.Vendor == "" {
+		return nil, errors.New("empty detector vendor")
+	}
+
+	if d.Check == nil {
+		return nil, errors.New("empty detector check")
+	}
+
+	check, err := d.Check.compile()
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't compile check of %s", d.Name)
+	}
+
+	return &Detector{
+		WAFName: d.Name,
+		Vendor:  d.Vendor,
+		Check:   check,
+	}, nil
+}
+
+func (c *yamlCheck) compile() (Check, error) {
+	var (
+		checks []Check
+		kinds  []string
+	)
+
+	attack := func(defaultValue bool) (bool, error) {
+		switch c.Response {
+		case "":
+			return defaultValue, nil
+		case "attack":
+			return true, nil
+		case "benign":
+			return false, nil
+		default:
+			return false, errors.Errorf("unknown response %q, expected attack or benign", c.Response)
+		}
+	}
+
+	if c.And != nil {
+		kinds = append(kinds, "and")
+
+		subChecks, err := compileAll(c.And)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, And(subChecks...))
+	}
+
+	if c.Or != nil {
+		kinds = append(kinds, "or")
+
+		subChecks, err := compileAll(c.Or)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, Or(subChecks...))
+	}
+
+	if c.Not != nil {
+		kinds = append(kinds, "not")
+
+		subCheck, err := c.Not.compile()
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, Not(subCheck))
+	}
+
+	if c.Status != 0 {
+		kinds = append(kinds, "status")
+
+		isAttack, err := attack(true)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, CheckStatusCode(c.Status, isAttack))
+	}
+
+	if c.Reason != "" {
+		kinds = append(kinds, "reason")
+
+		isAttack, err := attack(true)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = regexp.Compile(c.Reason); err != nil {
+			return nil, errors.Wrap(err, "couldn't compile reason regex")
+		}
+		checks = append(checks, CheckReason(c.Reason, isAttack))
+	}
+
+	if c.Header != nil {
+		kinds = append(kinds, "header")
+
+		isAttack, err := attack(false)
+		if err != nil {
+			return nil, err
+		}
+		if c.Header.Name == "" {
+			return nil, errors.New("empty header name")
+		}
+		if _, err = regexp.Compile(c.Header.Regex); err != nil {
+			return nil, errors.Wrap(err, "couldn't compile header regex")
+		}
+		checks = append(checks, CheckHeader(c.Header.Name, c.Header.Regex, isAttack))
+	}
+
+	if c.Cookie != "" {
+		kinds = append(kinds, "cookie")
+
+		isAttack, err := attack(false)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = regexp.Compile(c.Cookie); err != nil {
+			return nil, errors.Wrap(err, "couldn't compile cookie regex")
+		}
+		checks = append(checks, CheckCookie(c.Cookie, isAttack))
+	}
+
+	if c.Body != "" {
+		kinds = append(kinds, "body")
+
+		isAttack, err := attack(true)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = regexp.Compile(c.Body); err != nil {
+			return nil, errors.Wrap(err, "couldn't compile body regex")
+		}
+		checks = append(checks, CheckContent(c.Body, isAttack))
+	}
+
+	if len(checks) != 1 {
+		if len(checks) == 0 {
+			return nil, errors.New("empty check")
+		}
+
+		return nil, errors.Errorf("check must contain exactly one condition, got: %s", strings.Join(kinds, ", "))
+	}
+
+	return checks[0], nil
+}
+
+func compileAll(yamlChecks []*yamlCheck) ([]Check, error) {
+	if len(yamlChecks) == 0 {
+		return nil, errors.New("empty list of checks")
+	}
+
+	checks := make([]Check, 0, len(yamlChecks))
+	for _, c := range yamlChecks {
+		if c == nil {
+			return nil, errors.New("empty check")
+		}
+
+		check, err := c.compile()
+		if err != nil {
+			return nil, err
+		}
+
+		checks = append(checks, check)
+	}
+
+	return checks, nil
+}
+
+// LoadDetectors returns the built-in detectors followed by the detectors
+// loaded from the YAML files in dir. dir may be empty.
+func LoadDetectors(dir string) ([]*Detector, error) {
+	detectors := make([]*Detector, len(Detectors))
+	copy(detectors, Detectors)
+
+	builtin, err := loadDetectorsFS(definitions, "definitions")
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't load built-in detectors")
+	}
+	detectors = append(detectors, builtin...)
+
+	if dir != "" {
+		custom, err := loadDetectorsFS(os.DirFS(dir), ".")
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't load detectors from %s", dir)
+		}
+		detectors = append(detectors, custom...)
+	}
+
+	return detectors, nil
+}
+
+// loadDetectorsFS loads detectors from the YAML files in the directory of
+// fsys ordered by the file name.
+func loadDetectorsFS(fsys fs.FS, dir string) ([]*Detector, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	var detectors []*Detector
+
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yml" && ext != ".yaml") {
+			continue
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		d, err := ParseDetector(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't parse %s", entry.Name())
+		}
+
+		detectors = append(detectors, d)
+	}
+
+	return detectors, nil
+}
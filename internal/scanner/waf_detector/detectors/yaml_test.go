@@ -0,0 +1,161 @@
+package detectors
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/wallarm/gotestwaf/internal/scanner/types"
+)
+
+func TestParseDetector(t *testing.T) {
+	d, err := ParseDetector([]byte(`
+name: Example WAF
+vendor: Example Inc.
+check:
+  or:
+    - header: {name: Server, regex: example-waf}
+    - and:
+        - status: 403
+        - body: blocked by example
+        - not:
+            reason: Forbidden
+`))
+	if err != nil {
+		t.Fatalf("couldn't parse detector: %v", err)
+	}
+
+	testCases := []struct {
+		name  string
+		resps *Responses
+		want  bool
+	}{
+		{
+			name: "server header",
+			resps: &Responses{
+				Resp: &types.ResponseMeta{StatusCode: 200, Headers: http.Header{"Server": {"example-waf/1.0"}}},
+			},
+			want: true,
+		},
+		{
+			name: "block page",
+			resps: &Responses{
+				RespToAttack: &types.ResponseMeta{StatusCode: 403, StatusReason: "Denied", Content: []byte("request blocked by example")},
+			},
+			want: true,
+		},
+		{
+			name: "not matched reason",
+			resps: &Responses{
+				RespToAttack: &types.ResponseMeta{StatusCode: 403, StatusReason: "Forbidden", Content: []byte("request blocked by example")},
+			},
+			want: false,
+		},
+		{
+			name: "header in attack response",
+			resps: &Responses{
+				RespToAttack: &types.ResponseMeta{StatusCode: 200, Headers: http.Header{"Server": {"example-waf/1.0"}}},
+			},
+			want: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		if got := d.IsWAF(tc.resps); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestParseDetectorErrors(t *testing.T) {
+	testCases := map[string]string{
+		"no name":        "vendor: v\ncheck: {status: 403}",
+		"no check":       "name: n\nvendor: v",
+		"two conditions": "name: n\nvendor: v\ncheck: {status: 403, body: x}",
+		"bad regex":      "name: n\nvendor: v\ncheck: {body: \"(\"}",
+		"bad response":   "name: n\nvendor: v\ncheck: {status: 403, response: both}",
+		"unknown field":  "name: n\nvendor: v\ncheck: {code: 403}",
+		"empty and":      "name: n\nvendor: v\ncheck: {and: []}",
+	}
+
+	for name, data := range testCases {
+		if _, err := ParseDetector([]byte(data)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoadDetectors(t *testing.T) {
+	detectors, err := LoadDetectors("")
+	if err != nil {
+		t.Fatalf("couldn't load built-in detectors: %v", err)
+	}
+
+	if len(detectors) <= len(Detectors) {
+		t.Fatalf("built-in YAML detectors weren't loaded")
+	}
+}
+
+func TestExplain(t *testing.T) {
+	d := &Detector{
+		WAFName: "Example WAF",
+		Vendor:  "Example Inc.",
+		Check: Or(
+			And(
+				CheckStatusCode(403, true),
+				CheckContent("never matches", true),
+			),
+			And(
+				CheckStatusCode(403, true),
+				CheckHeader("X-Blocked-By", "example", true),
+			),
+		),
+	}
+
+	ok, checks := d.Explain(&Responses{
+		RespToAttack: &types.ResponseMeta{
+			StatusCode: 403,
+			Headers:    http.Header{"X-Blocked-By": {"example"}},
+		},
+	})
+	if !ok {
+		t.Fatalf("detector didn't match")
+	}
+
+	want := []string{
+		`status code is 403 in attack response`,
+		`header X-Blocked-By matches "example" in attack response`,
+	}
+	if strings.Join(checks, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got checks %q, want %q", checks, want)
+	}
+}
+
+func TestIsBlockPage(t *testing.T) {
+	data, err := definitions.ReadFile("definitions/cloudflare.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := ParseDetector(data)
+	if err != nil {
+		t.Fatalf("couldn't parse detector: %v", err)
+	}
+
+	headers := http.Header{"Server": {"cloudflare"}, "Cf-Ray": {"8a1b2c3d4e5f6789-AMS"}}
+
+	if d.IsBlockPage(&types.ResponseMeta{StatusCode: 403, Headers: headers, Content: []byte("<h1>Access denied</h1>")}) {
+		t.Errorf("block page of the WAF behind Cloudflare is attributed to Cloudflare")
+	}
+
+	if !d.IsBlockPage(&types.ResponseMeta{StatusCode: 403, Headers: headers, Content: []byte("Cloudflare Ray ID: 8a1b2c3d4e5f6789")}) {
+		t.Errorf("Cloudflare block page isn't attributed to Cloudflare")
+	}
+
+	// The conditions of the benign response are unknown, so their
+	// inversion doesn't match
+	d = &Detector{Check: Not(CheckHeader("Server", "cloudflare", false))}
+	if d.IsBlockPage(&types.ResponseMeta{StatusCode: 403}) {
+		t.Errorf("inverted condition of the benign response attributes the block")
+	}
+}
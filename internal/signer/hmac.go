@@ -0,0 +1,192 @@
+package signer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/wallarm/gotestwaf/internal/config"
+)
+
+const (
+	defaultHMACHeader    = "X-Signature"
+	defaultHMACSeparator = "\n"
+
+	headerComponentPrefix = "header:"
+)
+
+var defaultHMACComponents = []string{"method", "path", "query", "body-sha256"}
+
+var encoders = map[string]func([]byte) string{
+	"hex":       hex.EncodeToString,
+	"base64":    base64.StdEncoding.EncodeToString,
+	"base64url": base64.RawURLEncoding.EncodeToString,
+}
+
+var timestampFormatters = map[string]func(time.Time) string{
+	"unix":    func(t time.Time) string { return strconv.FormatInt(t.Unix(), 10) },
+	"unix-ms": func(t time.Time) string { return strconv.FormatInt(t.UnixMilli(), 10) },
+	"rfc3339": func(t time.Time) string { return t.UTC().Format(time.RFC3339) },
+}
+
+var _ Signer = (*HMACSigner)(nil)
+
+// HMACSigner signs requests with HMAC-SHA256 over the configured request
+// components and puts the signature into a header.
+type HMACSigner struct {
+	secret []byte
+
+	components []string
+	separator  string
+	sortQuery  bool
+
+	header string
+	prefix string
+	encode func([]byte) string
+
+	timestampHeader string
+	formatTimestamp func(time.Time) string
+
+	now func() time.Time
+}
+
+func NewHMACSigner(cfg *config.HMACSigningConfig) (*HMACSigner, error) {
+	secret := cfg.Secret
+	if cfg.SecretEnv != "" {
+		secret = os.Getenv(cfg.SecretEnv)
+	}
+	if secret == "" {
+		return nil, errors.New("hmac: empty secret")
+	}
+
+	s := &HMACSigner{
+		secret:          []byte(secret),
+		components:      cfg.Components,
+		separator:       cfg.Separator,
+		sortQuery:       cfg.SortQuery,
+		header:          cfg.Header,
+		prefix:          cfg.Prefix,
+		timestampHeader: cfg.TimestampHeader,
+		now:             time.Now,
+	}
+
+	if len(s.components) == 0 {
+		s.components = defaultHMACComponents
+	}
+	if s.separator == "" {
+		s.separator = defaultHMACSeparator
+	}
+	if s.header == "" {
+		s.header = defaultHMACHeader
+	}
+
+	encoding := cfg.Encoding
+	if encoding == "" {
+		encoding = "hex"
+	}
+
+	var ok bool
+	s.encode, ok = encoders[encoding]
+	if !ok {
+		return nil, errors.Errorf("hmac: unknown encoding: %s", encoding)
+	}
+
+	timestampFormat := cfg.TimestampFormat
+	if timestampFormat == "" {
+		timestampFormat = "unix"
+	}
+
+	s.formatTimestamp, ok = timestampFormatters[timestampFormat]
+	if !ok {
+		return nil, errors.Errorf("hmac: unknown timestamp format: %s", timestampFormat)
+	}
+
+	for _, c := range s.components {
+		switch {
+		case c == "method", c == "host", c == "path", c == "query", c == "body", c == "body-sha256", c == "timestamp":
+		case strings.HasPrefix(c, headerComponentPrefix):
+		default:
+			return nil, errors.Errorf("hmac: unknown component: %s", c)
+		}
+	}
+
+	return s, nil
+}
+
+func (s *HMACSigner) Sign(req *http.Request) error {
+	timestamp := s.formatTimestamp(s.now())
+	if s.timestampHeader != "" {
+		req.Header.Set(s.timestampHeader, timestamp)
+	}
+
+	var body []byte
+	var err error
+
+	parts := make([]string, 0, len(s.components))
+
+	for _, c := range s.components {
+		var part string
+
+		switch c {
+		case "method":
+			part = req.Method
+		case "host":
+			part = host(req)
+		case "path":
+			part = req.URL.EscapedPath()
+		case "query":
+			part = req.URL.RawQuery
+			if s.sortQuery {
+				part = sortQuery(part)
+			}
+		case "body", "body-sha256":
+			if body == nil {
+				body, err = readBody(req)
+				if err != nil {
+					return err
+				}
+			}
+
+			if c == "body" {
+				part = string(body)
+			} else {
+				hash := sha256.Sum256(body)
+				part = hex.EncodeToString(hash[:])
+			}
+		case "timestamp":
+			part = timestamp
+		default:
+			part = req.Header.Get(strings.TrimPrefix(c, headerComponentPrefix))
+		}
+
+		parts = append(parts, part)
+	}
+
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(strings.Join(parts, s.separator)))
+
+	req.Header.Set(s.header, s.prefix+s.encode(mac.Sum(nil)))
+
+	return nil
+}
+
+// sortQuery sorts query parameters without changing their encoding.
+func sortQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	params := strings.Split(rawQuery, "&")
+	sort.Strings(params)
+
+	return strings.Join(params, "&")
+}
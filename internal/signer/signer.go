@@ -0,0 +1,93 @@
+package signer
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/wallarm/gotestwaf/internal/config"
+)
+
+const (
+	HMACType     = "hmac"
+	AWSSigV4Type = "aws-sigv4"
+)
+
+// Signer adds a signature to the request. It is called after the payload is
+// placed and all headers are set, right before the request is sent.
+type Signer interface {
+	Sign(req *http.Request) error
+}
+
+// New creates a Signer from the config. If signing is not configured, nil is
+// returned.
+func New(cfg *config.SigningConfig) (Signer, error) {
+	if cfg == nil || cfg.Type == "" {
+		return nil, nil
+	}
+
+	switch strings.ToLower(cfg.Type) {
+	case HMACType:
+		return NewHMACSigner(&cfg.HMAC)
+	case AWSSigV4Type:
+		return NewAWSSigV4Signer(&cfg.AWS)
+	default:
+		return nil, errors.Errorf("unknown signing type: %s, expected %s or %s", cfg.Type, HMACType, AWSSigV4Type)
+	}
+}
+
+// Transport signs each request before passing it to the underlying
+// RoundTripper. The request is cloned, so the original request stays
+// untouched.
+type Transport struct {
+	Base   http.RoundTripper
+	Signer Signer
+}
+
+var _ http.RoundTripper = (*Transport)(nil)
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := req.Clone(req.Context())
+
+	if err := t.Signer.Sign(r); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+
+		return nil, errors.Wrap(err, "couldn't sign request")
+	}
+
+	return t.Base.RoundTrip(r)
+}
+
+// readBody returns the request body and makes it readable again.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading request body")
+	}
+	req.Body.Close()
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+
+	return body, nil
+}
+
+// host returns the value of the Host header that will be sent.
+func host(req *http.Request) string {
+	if req.Host != "" {
+		return req.Host
+	}
+
+	return req.URL.Host
+}
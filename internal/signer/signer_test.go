@@ -0,0 +1,144 @@
+package signer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wallarm/gotestwaf/internal/config"
+)
+
+func TestAWSSigV4Signer(t *testing.T) {
+	// "get-vanilla" and "get-vanilla-query-order-key-case" cases from the
+	// AWS Signature Version 4 test suite
+	testCases := []struct {
+		url       string
+		signature string
+	}{
+		{
+			url:       "https://example.amazonaws.com/",
+			signature: "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			url:       "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			signature: "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+	}
+
+	s, err := NewAWSSigV4Signer(&config.AWSSigningConfig{
+		Region:          "us-east-1",
+		Service:         "service",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	})
+	if err != nil {
+		t.Fatalf("couldn't create signer: %v", err)
+	}
+
+	s.now = func() time.Time {
+		return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	}
+
+	for _, tc := range testCases {
+		req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err = s.Sign(req); err != nil {
+			t.Fatalf("couldn't sign request: %v", err)
+		}
+
+		want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+			"SignedHeaders=host;x-amz-date, Signature=" + tc.signature
+
+		if got := req.Header.Get("Authorization"); got != want {
+			t.Errorf("%s:\n got: %s\nwant: %s", tc.url, got, want)
+		}
+	}
+}
+
+func TestHMACSigner(t *testing.T) {
+	s, err := NewHMACSigner(&config.HMACSigningConfig{
+		Secret:          "secret",
+		Components:      []string{"method", "path", "query", "timestamp", "header:X-Client", "body"},
+		Separator:       "|",
+		SortQuery:       true,
+		Header:          "X-Sig",
+		Prefix:          "v1=",
+		TimestampHeader: "X-Timestamp",
+	})
+	if err != nil {
+		t.Fatalf("couldn't create signer: %v", err)
+	}
+
+	s.now = func() time.Time {
+		return time.Unix(1700000000, 0)
+	}
+
+	var gotBody string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte("POST|/api/a%20b|a=1&b=2|1700000000|gtw|<script>"))
+
+		if r.Header.Get("X-Sig") != "v1="+hex.EncodeToString(mac.Sum(nil)) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/api/a%20b?b=2&a=1", strings.NewReader("<script>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Client", "gtw")
+
+	client := &http.Client{Transport: &Transport{Base: http.DefaultTransport, Signer: s}}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("couldn't send request: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("got status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if gotBody != "<script>" {
+		t.Errorf("got body %q, want %q", gotBody, "<script>")
+	}
+	if req.Header.Get("X-Sig") != "" {
+		t.Error("original request must not be modified")
+	}
+}
+
+func TestNew(t *testing.T) {
+	s, err := New(nil)
+	if s != nil || err != nil {
+		t.Errorf("expected no signer, got %v, %v", s, err)
+	}
+
+	badConfigs := []*config.SigningConfig{
+		{Type: "unknown"},
+		{Type: HMACType},
+		{Type: HMACType, HMAC: config.HMACSigningConfig{Secret: "s", Encoding: "base32"}},
+		{Type: HMACType, HMAC: config.HMACSigningConfig{Secret: "s", Components: []string{"cookie"}}},
+		{Type: AWSSigV4Type, AWS: config.AWSSigningConfig{AccessKeyID: "a", SecretAccessKey: "b"}},
+	}
+
+	for i, cfg := range badConfigs {
+		if _, err = New(cfg); err == nil {
+			t.Errorf("expected error for config #%d", i)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package signer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/wallarm/gotestwaf/internal/config"
+)
+
+const (
+	sigV4Algorithm   = "AWS4-HMAC-SHA256"
+	sigV4DateFormat  = "20060102T150405Z"
+	sigV4ShortDate   = "20060102"
+	sigV4Terminator  = "aws4_request"
+	s3Service        = "s3"
+	amzDateHeader    = "X-Amz-Date"
+	amzTokenHeader   = "X-Amz-Security-Token"
+	amzContentSHA256 = "X-Amz-Content-Sha256"
+)
+
+var _ Signer = (*AWSSigV4Signer)(nil)
+
+// AWSSigV4Signer signs requests with AWS Signature Version 4.
+type AWSSigV4Signer struct {
+	region          string
+	service         string
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+
+	now func() time.Time
+}
+
+func NewAWSSigV4Signer(cfg *config.AWSSigningConfig) (*AWSSigV4Signer, error) {
+	s := &AWSSigV4Signer{
+		region:          cfg.Region,
+		service:         cfg.Service,
+		accessKeyID:     cfg.AccessKeyID,
+		secretAccessKey: cfg.SecretAccessKey,
+		sessionToken:    cfg.SessionToken,
+		now:             time.Now,
+	}
+
+	if s.accessKeyID == "" && s.secretAccessKey == "" {
+		s.accessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		s.secretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		if s.sessionToken == "" {
+			s.sessionToken = os.Getenv("AWS_SESSION_TOKEN")
+		}
+	}
+
+	if s.region == "" {
+		s.region = os.Getenv("AWS_REGION")
+	}
+
+	if s.region == "" || s.service == "" {
+		return nil, errors.New("aws-sigv4: region and service must be set")
+	}
+
+	if s.accessKeyID == "" || s.secretAccessKey == "" {
+		return nil, errors.New("aws-sigv4: credentials are not set")
+	}
+
+	return s, nil
+}
+
+func (s *AWSSigV4Signer) Sign(req *http.Request) error {
+	now := s.now().UTC()
+	amzDate := now.Format(sigV4DateFormat)
+	shortDate := now.Format(sigV4ShortDate)
+
+	req.Header.Set(amzDateHeader, amzDate)
+	if s.sessionToken != "" {
+		req.Header.Set(amzTokenHeader, s.sessionToken)
+	}
+
+	body, err := readBody(req)
+	if err != nil {
+		return err
+	}
+
+	payloadHash := sha256.Sum256(body)
+	payloadHashHex := hex.EncodeToString(payloadHash[:])
+
+	if s.service == s3Service {
+		req.Header.Set(amzContentSHA256, payloadHashHex)
+	}
+
+	signedHeaders, canonicalHeaders := s.canonicalHeaders(req)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s.canonicalURI(req.URL),
+		canonicalQuery(req.URL.RawQuery),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHashHex,
+	}, "\n")
+
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	scope := strings.Join([]string{shortDate, s.region, s.service, sigV4Terminator}, "/")
+
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		amzDate,
+		scope,
+		hex.EncodeToString(canonicalRequestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), shortDate)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s.service)
+	key = hmacSHA256(key, sigV4Terminator)
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, s.accessKeyID, scope, signedHeaders, signature,
+	))
+
+	return nil
+}
+
+// canonicalHeaders returns the list of signed headers and the canonical
+// headers string. Only the headers required by AWS are signed, so proxies
+// and the WAF may change the other ones without breaking the signature.
+func (s *AWSSigV4Signer) canonicalHeaders(req *http.Request) (signed string, canonical string) {
+	headers := map[string]string{
+		"host": host(req),
+	}
+
+	for _, h := range []string{"Content-Type", amzDateHeader, amzTokenHeader, amzContentSHA256} {
+		if v := req.Header.Get(h); v != "" {
+			headers[strings.ToLower(h)] = strings.Join(strings.Fields(v), " ")
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, name := range names {
+		sb.WriteString(name)
+		sb.WriteByte(':')
+		sb.WriteString(headers[name])
+		sb.WriteByte('\n')
+	}
+
+	return strings.Join(names, ";"), sb.String()
+}
+
+// canonicalURI returns the URI-encoded path. All services except S3 expect
+// the path to be encoded twice.
+func (s *AWSSigV4Signer) canonicalURI(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+
+	if s.service == s3Service {
+		return path
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery returns the query string with URI-encoded parameters sorted
+// by name and value.
+func canonicalQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	var params []string
+
+	for _, param := range strings.Split(rawQuery, "&") {
+		if param == "" {
+			continue
+		}
+
+		key, value, _ := strings.Cut(param, "=")
+		params = append(params, uriEncode(queryUnescape(key))+"="+uriEncode(queryUnescape(value)))
+	}
+
+	sort.Strings(params)
+
+	return strings.Join(params, "&")
+}
+
+// queryUnescape decodes the query parameter. Payloads can contain invalid
+// escape sequences, such values are used as is.
+func queryUnescape(s string) string {
+	unescaped, err := url.QueryUnescape(s)
+	if err != nil {
+		return s
+	}
+
+	return unescaped
+}
+
+// uriEncode encodes all bytes except the unreserved characters as defined
+// by RFC 3986, as AWS requires.
+func uriEncode(s string) string {
+	var sb strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+
+	return sb.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
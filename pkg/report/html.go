@@ -63,8 +63,14 @@ type HtmlReport struct {
 	UnresolvedRequestsNumber int `json:"unresolved_requests_number" validate:"min=0"`
 	FailedRequestsNumber     int `json:"failed_requests_number" validate:"min=0"`
 
+	// AppRejectedRequestsNumber is the number of unresolved requests rejected
+	// by the application as the benign ones
+	AppRejectedRequestsNumber int `json:"app_rejected_requests_number" validate:"min=0"`
+
 	ScannedPaths db.ScannedPaths `json:"scanned_paths" validate:"omitempty,max=2048,dive,required"`
 
+	LatencyTable []*LatencyTableRow `json:"latency_table" validate:"omitempty,max=1024,dive,required"`
+
 	TruePositiveTests struct {
 		SummaryTable map[string]*TestSetSummary `json:"summary_table" validate:"omitempty,dive,keys,required,max=256,endkeys,required"`
 
@@ -74,12 +80,13 @@ type HtmlReport struct {
 		Unresolved map[string]map[int]*TestDetails `json:"unresolved" validate:"omitempty,dive,keys,required,max=256000,endkeys,required,dive,keys,min=0,endkeys,required"`
 		Failed     []*db.FailedDetails             `json:"failed" validate:"omitempty,dive,required"`
 
-		Percentage               float64 `json:"percentage" validate:"min=0,max=100"`
-		TotalSent                int     `json:"total_sent" validate:"min=0"`
-		BlockedRequestsNumber    int     `json:"blocked_requests_number" validate:"min=0"`
-		BypassedRequestsNumber   int     `json:"bypassed_requests_number" validate:"min=0"`
-		UnresolvedRequestsNumber int     `json:"unresolved_requests_number" validate:"min=0"`
-		FailedRequestsNumber     int     `json:"failed_requests_number" validate:"min=0"`
+		Percentage                float64 `json:"percentage" validate:"min=0,max=100"`
+		TotalSent                 int     `json:"total_sent" validate:"min=0"`
+		BlockedRequestsNumber     int     `json:"blocked_requests_number" validate:"min=0"`
+		BypassedRequestsNumber    int     `json:"bypassed_requests_number" validate:"min=0"`
+		UnresolvedRequestsNumber  int     `json:"unresolved_requests_number" validate:"min=0"`
+		FailedRequestsNumber      int     `json:"failed_requests_number" validate:"min=0"`
+		AppRejectedRequestsNumber int     `json:"app_rejected_requests_number" validate:"min=0"`
 	} `json:"true_positive_tests"`
 
 	TrueNegativeTests struct {
@@ -93,12 +100,13 @@ type HtmlReport struct {
 		Unresolved map[string]map[int]*TestDetails `json:"unresolved" validate:"omitempty,dive,keys,required,max=256000,endkeys,required,dive,keys,min=0,endkeys,required"`
 		Failed     []*db.FailedDetails             `json:"failed" validate:"omitempty,dive,required"`
 
-		Percentage               float64 `json:"percentage" validate:"min=0,max=100"`
-		TotalSent                int     `json:"total_sent" validate:"min=0"`
-		BlockedRequestsNumber    int     `json:"blocked_requests_number" validate:"min=0"`
-		BypassedRequestsNumber   int     `json:"bypassed_requests_number" validate:"min=0"`
-		UnresolvedRequestsNumber int     `json:"unresolved_requests_number" validate:"min=0"`
-		FailedRequestsNumber     int     `json:"failed_requests_number" validate:"min=0"`
+		Percentage                float64 `json:"percentage" validate:"min=0,max=100"`
+		TotalSent                 int     `json:"total_sent" validate:"min=0"`
+		BlockedRequestsNumber     int     `json:"blocked_requests_number" validate:"min=0"`
+		BypassedRequestsNumber    int     `json:"bypassed_requests_number" validate:"min=0"`
+		UnresolvedRequestsNumber  int     `json:"unresolved_requests_number" validate:"min=0"`
+		FailedRequestsNumber      int     `json:"failed_requests_number" validate:"min=0"`
+		AppRejectedRequestsNumber int     `json:"app_rejected_requests_number" validate:"min=0"`
 	} `json:"true_negative_tests"`
 }
 
@@ -119,6 +127,19 @@ type TestDetails struct {
 	TestCase     string         `json:"test_case" validate:"required,printascii,max=256"`
 	Encoders     map[string]any `json:"encoders" validate:"required,encoders"`
 	Placeholders map[string]any `json:"placeholders" validate:"required,placeholders"`
+
+	// Evidence of one of the grouped tests if the evidence was saved
+	Evidence *db.Evidence `json:"evidence,omitempty" validate:"-"`
+
+	// AppRejected is true if one of the grouped tests was rejected by the
+	// application as the benign request
+	AppRejected bool `json:"app_rejected,omitempty" validate:"boolean"`
+
+	// BlockedBy contains names of the identified WAFs whose signatures
+	// matched the responses of the grouped tests
+	BlockedBy map[string]any `json:"blocked_by,omitempty" validate:"omitempty,dive,keys,required,printascii,max=256,endkeys"`
+
+	db.Metadata
 }
 
 type TestSetSummary struct {
@@ -134,6 +155,17 @@ type TestSetSummary struct {
 	ResolvedTestCasesNumber int `json:"resolved_test_cases_number" validate:"min=0"`
 }
 
+// LatencyTableRow contains the response time distribution of a test set in
+// milliseconds.
+type LatencyTableRow struct {
+	TestSet  string `json:"test_set" validate:"required,printascii,max=256"`
+	Requests int    `json:"requests" validate:"min=0"`
+	Min      int64  `json:"min" validate:"min=0"`
+	Median   int64  `json:"median" validate:"min=0"`
+	P90      int64  `json:"p90" validate:"min=0"`
+	Max      int64  `json:"max" validate:"min=0"`
+}
+
 // RenderFullReportToHTML substitutes report data into HTML template.
 func RenderFullReportToHTML(reportData *HtmlReport) (*bytes.Buffer, error) {
 	apiChart, appChart, err := generateCharts(
@@ -16,7 +16,7 @@ var (
 	markRegex       = regexp.MustCompile(`^(N/A|[A-F][\+\-]?)$`)
 	suffixRegex     = regexp.MustCompile(`^(na|[a-f])$`)
 	indicatorRegex  = regexp.MustCompile(`^(-|[[:print:]]{1,30} \((unavailable|[0-9]{1,3}\.[0-9]%)\))$`)
-	argsRegex       = regexp.MustCompile(`^\-\-((quiet|tlsVerify|followCookies|renewSession|skipWAFIdentification|nonBlockedAsPassed|noEmailReport|ignoreUnresolved|blockConnReset|skipWAFBlockCheck|addDebugHeader|includePayloads)|(configPath|logFormat|logLevel|url|wsURL|graphqlURL|proxy|blockRegex|passRegex|testCase|testSet|reportPath|reportName|reportFormat|email|testCasesPath|wafName|addHeader|openapiFile)\=[[:print:]]+|(grpcPort|maxIdleConns|maxRedirects|idleConnTimeout|workers|sendDelay|randomDelay)\=\d+|(blockStatusCodes|passStatusCodes)\=[\d,]+)$`)
+	argsRegex       = regexp.MustCompile(`^\-\-((quiet|tlsVerify|followCookies|renewSession|skipWAFIdentification|nonBlockedAsPassed|noEmailReport|ignoreUnresolved|blockConnReset|skipWAFBlockCheck|addDebugHeader|includePayloads|solveJSChallenge|streamFirstEventOnly|calibrate|baseline|includeEvidence|dryRun)|(configPath|logFormat|logLevel|url|wsURL|graphqlURL|proxy|blockRegex|passRegex|testCase|testSet|reportPath|reportName|reportFormat|email|testCasesPath|addTestCasesPath|wafName|addHeader|openapiFile|tlsClientCert|tlsClientKey|tlsCA|tlsServerName|tlsMinVersion|tlsMaxVersion|wafDetectorsPath|wafDetector|tarpitDetection|include|exclude|templateVar|dryRunDump)\=[[:print:]]+|(grpcPort|maxIdleConns|maxRedirects|idleConnTimeout|workers|sendDelay|randomDelay|jsChallengeTimeout|maxResponseSize|responseReadTimeout|tarpitTimeout|evidenceBodySize)\=\d+|templateSeed\=\-?\d+|(blockStatusCodes|passStatusCodes)\=[\d,]+)$`)
 )
 
 var customValidators = map[string]validator.Func{
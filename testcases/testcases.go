@@ -0,0 +1,10 @@
+// Package testcases embeds the default test cases into the binary. They are
+// used if the test cases directory doesn't exist.
+package testcases
+
+import "embed"
+
+// FS contains the test sets of the default test cases.
+//
+//go:embed *
+var FS embed.FS